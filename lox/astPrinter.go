@@ -25,12 +25,98 @@ func (p *AstPrinter) VisitBlockStmt(stmt *Block) (interface{}, error) {
 
 func (p *AstPrinter) VisitClassStmt(stmt *Class) (interface{}, error) {
 	var sb strings.Builder
-	sb.WriteString("(class " + stmt.name.lexeme)
+	p.decorators(&sb, stmt.decorators)
+	methods := stmt.methods
+	if stmt.record {
+		// the generated initializer is printed as the record's fields
+		sb.WriteString("(record " + stmt.name.lexeme + "(")
+		p.parameters(&sb, methods[0].params)
+		sb.WriteString(")")
+		methods = methods[1:]
+	} else {
+		sb.WriteString("(class " + stmt.name.lexeme)
+	}
 
 	if stmt.superclass != nil {
 		sb.WriteString(" < " + p.print(stmt.superclass))
 	}
 
+	if len(stmt.traits) > 0 {
+		sb.WriteString(" with")
+		for _, trait := range stmt.traits {
+			sb.WriteString(" " + p.print(trait))
+		}
+	}
+
+	if len(stmt.interfaces) > 0 {
+		sb.WriteString(" implements")
+		for _, iface := range stmt.interfaces {
+			sb.WriteString(" " + p.print(iface))
+		}
+	}
+
+	for _, method := range methods {
+		sb.WriteString(" " + p.printStmt(&method))
+	}
+
+	for _, method := range stmt.classMethods {
+		sb.WriteString(" (class " + p.printStmt(&method) + ")")
+	}
+
+	for _, field := range stmt.fields {
+		sb.WriteString(" " + p.printStmt(&field))
+	}
+
+	for _, field := range stmt.classFields {
+		sb.WriteString(" (class " + p.printStmt(&field) + ")")
+	}
+
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+// decorators prints the decorators in front of a declaration
+func (p *AstPrinter) decorators(sb *strings.Builder, decorators []Decorator) {
+	for _, decorator := range decorators {
+		sb.WriteString("@" + p.print(decorator.expression) + " ")
+	}
+}
+
+func (p *AstPrinter) VisitEnumStmt(stmt *Enum) (interface{}, error) {
+	var sb strings.Builder
+	sb.WriteString("(enum " + stmt.name.lexeme)
+	for _, member := range stmt.members {
+		sb.WriteString(" " + member.lexeme)
+	}
+	for _, method := range stmt.methods {
+		sb.WriteString(" " + p.printStmt(&method))
+	}
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+func (p *AstPrinter) VisitInterfaceStmt(stmt *Interface) (interface{}, error) {
+	var sb strings.Builder
+	sb.WriteString("(interface " + stmt.name.lexeme)
+
+	for _, method := range stmt.methods {
+		sb.WriteString(" " + method.name.lexeme)
+		if method.getter {
+			continue
+		}
+		sb.WriteString("(")
+		p.parameters(&sb, method.params)
+		sb.WriteString(")")
+	}
+
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+func (p *AstPrinter) VisitTraitStmt(stmt *Trait) (interface{}, error) {
+	var sb strings.Builder
+	sb.WriteString("(trait " + stmt.name.lexeme)
+
 	for _, method := range stmt.methods {
 		sb.WriteString(" " + p.printStmt(&method))
 	}
@@ -45,15 +131,23 @@ func (p *AstPrinter) VisitExpressionStmt(stmt *Expression) (interface{}, error)
 
 func (p *AstPrinter) VisitFunctionStmt(stmt *Function) (interface{}, error) {
 	var sb strings.Builder
-	sb.WriteString("(fun " + stmt.name.lexeme + "(")
-
-	for i, param := range stmt.params {
-		if i > 0 {
-			sb.WriteString(" ")
+	p.decorators(&sb, stmt.decorators)
+	if stmt.getter {
+		sb.WriteString("(get " + stmt.name.lexeme + " ")
+		for _, statement := range stmt.body {
+			sb.WriteString(p.printStmt(statement))
 		}
-		sb.WriteString(param.lexeme)
+		sb.WriteString(")")
+		return sb.String(), nil
 	}
 
+	if stmt.async {
+		sb.WriteString("(async fun " + stmt.name.lexeme + "(")
+	} else {
+		sb.WriteString("(fun " + stmt.name.lexeme + "(")
+	}
+
+	p.parameters(&sb, stmt.params)
 	sb.WriteString(") ")
 
 	for _, statement := range stmt.body {
@@ -83,16 +177,98 @@ func (p *AstPrinter) VisitReturnStmt(stmt *Return) (interface{}, error) {
 }
 
 func (p *AstPrinter) VisitVarStmt(stmt *Var) (interface{}, error) {
+	if stmt.constant {
+		return p.parenthesize2("const", stmt.name, "=", stmt.initializer)
+	}
 	if stmt.initializer == nil {
 		return p.parenthesize2("var", stmt.name)
 	}
 	return p.parenthesize2("var", stmt.name, "=", stmt.initializer)
 }
 
+func (p *AstPrinter) VisitDestructureVarStmt(stmt *DestructureVar) (interface{}, error) {
+	keyword := "var"
+	if stmt.constant {
+		keyword = "const"
+	}
+	return p.parenthesize2(keyword, p.pattern(stmt.pattern), "=", stmt.initializer)
+}
+
+func (p *AstPrinter) VisitDestructureExpr(expr *Destructure) (interface{}, error) {
+	return p.parenthesize2("=", p.pattern(expr.pattern), expr.value)
+}
+
+// pattern prints a destructuring pattern the way it was written
+func (p *AstPrinter) pattern(pattern Pattern) string {
+	switch pattern := pattern.(type) {
+	case *NamePattern:
+		return pattern.name.lexeme
+	case *TargetPattern:
+		return p.print(pattern.target)
+	case *ListPattern:
+		var parts []string
+		for _, element := range pattern.elements {
+			parts = append(parts, p.pattern(element.target)+p.patternDefault(element.defaultValue))
+		}
+		if pattern.rest != nil {
+			parts = append(parts, "..."+pattern.rest.lexeme)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	case *ObjectPattern:
+		var parts []string
+		for _, property := range pattern.properties {
+			part := property.name.lexeme
+			if target, ok := property.target.(*NamePattern); !ok || target.name.lexeme != property.name.lexeme {
+				part += ":" + p.pattern(property.target)
+			}
+			parts = append(parts, part+p.patternDefault(property.defaultValue))
+		}
+		return "{" + strings.Join(parts, " ") + "}"
+	case *WildcardPattern:
+		return "_"
+	case *ValuePattern:
+		return p.print(pattern.value)
+	case *ClassPattern:
+		return pattern.class.name.lexeme + p.pattern(pattern.object)
+	}
+	return ""
+}
+
+func (p *AstPrinter) patternDefault(defaultValue Expr) string {
+	if defaultValue == nil {
+		return ""
+	}
+	return "=" + p.print(defaultValue)
+}
+
+func (p *AstPrinter) VisitMatchStmt(stmt *Match) (interface{}, error) {
+	var cases []interface{}
+	for _, matchCase := range stmt.cases {
+		var parts []interface{}
+		for _, pattern := range matchCase.patterns {
+			parts = append(parts, p.pattern(pattern))
+		}
+		if matchCase.guard != nil {
+			parts = append(parts, "if", matchCase.guard)
+		}
+		parts = append(parts, "=>", matchCase.body)
+		result, err := p.parenthesize2("case", parts...)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, result)
+	}
+	return p.parenthesize2("match", stmt.subject, cases)
+}
+
 func (p *AstPrinter) VisitWhileStmt(stmt *While) (interface{}, error) {
 	return p.parenthesize2("while", stmt.condition, stmt.body)
 }
 
+func (p *AstPrinter) VisitForInStmt(stmt *ForIn) (interface{}, error) {
+	return p.parenthesize2("for-in", stmt.name, stmt.iterable, stmt.body)
+}
+
 func (p *AstPrinter) VisitSetExpr(expr *Set) (interface{}, error) {
 	return p.parenthesize2("=", expr.object, expr.name.lexeme, expr.value)
 }
@@ -106,9 +282,70 @@ func (p *AstPrinter) VisitThisExpr(expr *This) (interface{}, error) {
 }
 
 func (p *AstPrinter) VisitGetExpr(expr *Get) (interface{}, error) {
+	if expr.optional {
+		return p.parenthesize2("?.", expr.object, expr.name.lexeme)
+	}
 	return p.parenthesize2(".", expr.object, expr.name.lexeme)
 }
 
+func (p *AstPrinter) VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error) {
+	return expr.expression.Accept(p)
+}
+
+func (p *AstPrinter) VisitConditionalExpr(expr *Conditional) (interface{}, error) {
+	return p.parenthesize("?:", expr.condition, expr.thenBranch, expr.elseBranch)
+}
+
+func (p *AstPrinter) VisitIndexExpr(expr *Index) (interface{}, error) {
+	return p.parenthesize("[]", expr.object, expr.index)
+}
+
+func (p *AstPrinter) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) {
+	return p.parenthesize("[]=", expr.object, expr.index, expr.value)
+}
+
+func (p *AstPrinter) VisitListExpr(expr *List) (interface{}, error) {
+	return p.parenthesize("list", expr.elements...)
+}
+
+func (p *AstPrinter) VisitMapExpr(expr *Map) (interface{}, error) {
+	var entries []Expr
+	for i := range expr.keys {
+		entries = append(entries, expr.keys[i], expr.values[i])
+	}
+	return p.parenthesize("map", entries...)
+}
+
+func (p *AstPrinter) VisitRangeExpr(expr *Range) (interface{}, error) {
+	return p.parenthesize(expr.operator.lexeme, expr.start, expr.end)
+}
+
+func (p *AstPrinter) VisitSpawnExpr(expr *Spawn) (interface{}, error) {
+	return p.parenthesize("spawn", expr.call)
+}
+
+func (p *AstPrinter) VisitAwaitExpr(expr *Await) (interface{}, error) {
+	return p.parenthesize("await", expr.value)
+}
+
+func (p *AstPrinter) VisitYieldExpr(expr *Yield) (interface{}, error) {
+	if expr.value == nil {
+		return "(yield)", nil
+	}
+	return p.parenthesize("yield", expr.value)
+}
+
+func (p *AstPrinter) VisitCompoundExpr(expr *Compound) (interface{}, error) {
+	return p.parenthesize(expr.operator.lexeme, expr.target, expr.value)
+}
+
+func (p *AstPrinter) VisitUpdateExpr(expr *Update) (interface{}, error) {
+	if expr.prefix {
+		return p.parenthesize("pre"+expr.operator.lexeme, expr.target)
+	}
+	return p.parenthesize("post"+expr.operator.lexeme, expr.target)
+}
+
 func (p *AstPrinter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
 	return p.parenthesize(expr.operator.lexeme, expr.left, expr.right)
 }
@@ -152,7 +389,30 @@ func (p *AstPrinter) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 }
 
 func (p *AstPrinter) VisitCallExpr(expr *Call) (interface{}, error) {
-	return p.parenthesize2("call", expr.callee, expr.arguments)
+	parts := []interface{}{expr.callee}
+	for _, argument := range expr.arguments {
+		parts = append(parts, argument)
+	}
+	for _, argument := range expr.named {
+		parts = append(parts, argument.name.lexeme+":", argument.value)
+	}
+	return p.parenthesize2("call", parts...)
+}
+
+// parameters writes a parameter list the way it was declared
+func (p *AstPrinter) parameters(sb *strings.Builder, params []Parameter) {
+	for i, param := range params {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		if param.rest {
+			sb.WriteString("...")
+		}
+		sb.WriteString(param.name.lexeme)
+		if param.defaultValue != nil {
+			sb.WriteString("=" + p.print(param.defaultValue))
+		}
+	}
 }
 
 func (p *AstPrinter) parenthesize(name string, exprs ...Expr) (interface{}, error) {
@@ -224,5 +484,8 @@ func (p *AstPrinter) printStmt(stmt Stmt) string {
 	if err != nil {
 		return ""
 	}
+	if doc := docComment(stmt); doc != "" {
+		return fmt.Sprintf("(doc %q) ", doc) + result.(string)
+	}
 	return result.(string)
 }
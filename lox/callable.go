@@ -1,6 +1,29 @@
 package main
 
+import "fmt"
+
 type Callable interface {
 	call(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
-	arity() int
+	// arity returns the smallest and largest number of arguments that can be
+	// passed. The largest is -1 if any number of extra arguments is allowed
+	arity() (int, int)
+}
+
+// acceptsArguments returns true if the callable can be called with count
+// arguments
+func acceptsArguments(callable Callable, count int) bool {
+	min, max := callable.arity()
+	return count >= min && (max == -1 || count <= max)
+}
+
+// describeArity describes how many arguments are expected, for use in error
+// messages
+func describeArity(min int, max int) string {
+	if max == -1 {
+		return fmt.Sprintf("at least %d", min)
+	}
+	if min == max {
+		return fmt.Sprintf("%d", min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
 }
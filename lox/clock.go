@@ -4,11 +4,16 @@ import "time"
 
 type clock struct{}
 
-func (c clock) arity() int {
-	return 0
+func (c clock) arity() (int, int) {
+	return 0, 0
 }
 
+// call returns the current time in milliseconds, or the time on the event
+// loop's clock when it is virtual
 func (c clock) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if interpreter.loop != nil && interpreter.loop.virtual {
+		return interpreter.loop.now(), nil
+	}
 	return float64(time.Now().UnixMilli()), nil
 }
 
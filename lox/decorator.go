@@ -0,0 +1,74 @@
+package main
+
+// Decorator is one of the "@" decorators in front of a declaration. A
+// decorator that evaluates to a function is called with the declared
+// function or class and its result is used in place of it. Anything else,
+// such as a class or an instance, is kept as an annotation that can be
+// read back with the annotations() native
+type Decorator struct {
+	at         Token
+	expression Expr
+}
+
+// evaluateDecorators evaluates each decorator in the order they were
+// written, splitting them into annotations and decorator functions
+func (i *Interpreter) evaluateDecorators(decorators []Decorator) ([]interface{}, []Callable, error) {
+	var annotations []interface{}
+	var functions []Callable
+	for _, decorator := range decorators {
+		value, err := i.evaluate(decorator.expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		function, ok := value.(Callable)
+		if _, isClass := value.(*LoxClass); !ok || isClass {
+			annotations = append(annotations, value)
+			continue
+		}
+		if !acceptsArguments(function, 1) {
+			return nil, nil, &RuntimeError{decorator.at, "A decorator must take one argument."}
+		}
+		functions = append(functions, function)
+	}
+	return annotations, functions, nil
+}
+
+// applyDecorators passes the target through each decorator function,
+// starting with the one closest to the declaration
+func (i *Interpreter) applyDecorators(target interface{}, decorators []Callable, name Token) (interface{}, error) {
+	for index := len(decorators) - 1; index >= 0; index-- {
+		var err error
+		target, err = decorators[index].call(i, []interface{}{target})
+		if err != nil {
+			if runtimeError, ok := err.(*RuntimeError); ok && runtimeError.token.line == 0 {
+				runtimeError.token = name
+			}
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+// decorateMethod applies the decorators of a method once it has been bound,
+// so that they wrap a function that can use "this". An instance keeps its
+// decorated methods so each decorator runs once per instance
+func (i *Interpreter) decorateMethod(object interface{}, method LoxFunction, name Token) (interface{}, error) {
+	instance, ok := object.(*LoxInstance)
+	if ok {
+		if decorated, prs := instance.decoratedMethod(name.lexeme); prs {
+			return decorated, nil
+		}
+	}
+
+	decorators := method.decorators
+	method.decorators = nil
+	decorated, err := i.applyDecorators(method, decorators, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return instance.keepDecorated(name.lexeme, decorated), nil
+	}
+	return decorated, nil
+}
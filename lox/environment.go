@@ -1,7 +1,15 @@
 package main
 
+import "sync"
+
+// Envionment holds the variables of a scope. Spawned tasks share their
+// enclosing environments, including the globals, so every access to values
+// goes through the mutex. Instances, lists and maps guard their own
+// contents in the same way
 type Envionment struct {
+	mutex     sync.RWMutex
 	values    map[string]interface{}
+	constants map[string]bool
 	enclosing *Envionment
 }
 
@@ -9,12 +17,30 @@ func NewEnvironment(enclosing *Envionment) *Envionment {
 	return &Envionment{values: make(map[string]interface{}), enclosing: enclosing}
 }
 
+// define creates a variable, replacing any variable or constant that
+// already has that name in this environment
 func (e *Envionment) define(name string, value interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	e.values[name] = value
+	delete(e.constants, name)
+}
+
+// defineConstant creates a variable that assign refuses to change
+func (e *Envionment) defineConstant(name string, value interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.values[name] = value
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
 }
 
 func (e *Envionment) get(name Token) (interface{}, error) {
+	e.mutex.RLock()
 	value, exists := e.values[name.lexeme]
+	e.mutex.RUnlock()
 	if exists {
 		return value, nil
 	}
@@ -27,11 +53,17 @@ func (e *Envionment) get(name Token) (interface{}, error) {
 }
 
 func (e *Envionment) getAt(distance int, name string) interface{} {
-	return e.ancestor(distance).values[name]
+	environment := e.ancestor(distance)
+	environment.mutex.RLock()
+	defer environment.mutex.RUnlock()
+	return environment.values[name]
 }
 
 func (e *Envionment) assignAt(distance int, name Token, value interface{}) {
-	e.ancestor(distance).values[name.lexeme] = value
+	environment := e.ancestor(distance)
+	environment.mutex.Lock()
+	defer environment.mutex.Unlock()
+	environment.values[name.lexeme] = value
 }
 
 func (e *Envionment) ancestor(distance int) *Envionment {
@@ -43,9 +75,17 @@ func (e *Envionment) ancestor(distance int) *Envionment {
 }
 
 func (e *Envionment) assign(name Token, value interface{}) error {
+	e.mutex.Lock()
 	_, exists := e.values[name.lexeme]
-	if exists {
+	constant := e.constants[name.lexeme]
+	if exists && !constant {
 		e.values[name.lexeme] = value
+	}
+	e.mutex.Unlock()
+	if constant {
+		return &RuntimeError{name, "Can't assign to constant '" + name.lexeme + "'."}
+	}
+	if exists {
 		return nil
 	}
 
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"container/heap"
+	"time"
+)
+
+// EventLoop runs timer callbacks and promise callbacks once the main program
+// has finished, until there is nothing left waiting. It belongs to the main
+// program, so spawned tasks can't use timers, promises or async functions.
+// With a virtual clock, time jumps straight to the next timer instead of
+// sleeping, which keeps scripts that use timers fast and deterministic
+type EventLoop struct {
+	virtual    bool
+	start      time.Time
+	elapsed    float64
+	nextId     int
+	order      int
+	timers     timerQueue
+	active     map[int]*timer
+	microtasks []microtask
+	// promises rejected by a runtime error, which is reported if nothing
+	// handles it
+	failures []*LoxPromise
+}
+
+// microtask is a promise callback waiting to be run with the promise's value
+type microtask struct {
+	callback func(interface{}) error
+	value    interface{}
+}
+
+type timer struct {
+	id       int
+	due      float64
+	interval float64
+	order    int
+	callback Callable
+}
+
+func NewEventLoop() *EventLoop {
+	return &EventLoop{start: time.Now(), active: make(map[int]*timer)}
+}
+
+// now returns the number of milliseconds since the loop was created
+func (l *EventLoop) now() float64 {
+	if l.virtual {
+		return l.elapsed
+	}
+	return float64(time.Since(l.start).Milliseconds())
+}
+
+func (l *EventLoop) enqueue(callback func(interface{}) error, value interface{}) {
+	l.microtasks = append(l.microtasks, microtask{callback, value})
+}
+
+// schedule adds a timer that calls callback after delay milliseconds, and
+// then every interval milliseconds if interval isn't zero
+func (l *EventLoop) schedule(callback Callable, delay float64, interval float64) int {
+	l.nextId++
+	t := &timer{id: l.nextId, interval: interval, callback: callback}
+	l.active[t.id] = t
+	l.push(t, l.now()+delay)
+	return t.id
+}
+
+func (l *EventLoop) push(t *timer, due float64) {
+	l.order++
+	t.due = due
+	t.order = l.order
+	heap.Push(&l.timers, t)
+}
+
+func (l *EventLoop) cancel(id int) {
+	delete(l.active, id)
+}
+
+// await calls resolved with the value of a promise once it is resolved, or
+// with value itself on the next turn of the loop if it isn't a promise.
+// rejected is called with the reason if the promise is rejected
+func (l *EventLoop) await(value interface{}, resolved func(interface{}) error, rejected func(interface{}) error) {
+	if promise, ok := value.(*LoxPromise); ok {
+		promise.subscribe(resolved, rejected)
+		return
+	}
+	l.enqueue(resolved, value)
+}
+
+// async runs the body of an async function as a coroutine. It runs until its
+// first await straight away and is resumed with the awaited value each time
+// the loop gets to it, or with a rejection that makes the await fail. The
+// promise returned is resolved with what the body returns, or rejected if
+// the body fails with a runtime error
+func (l *EventLoop) async(coroutine *LoxGenerator) (*LoxPromise, error) {
+	promise := NewLoxPromise(l)
+	var step func(value interface{}) error
+	step = func(value interface{}) error {
+		result, finished, err := coroutine.resume(value)
+		if err != nil {
+			runtimeError, ok := err.(*RuntimeError)
+			if !ok {
+				return err
+			}
+			promise.fail(runtimeError)
+			return nil
+		}
+		if finished {
+			promise.resolve(result)
+		} else {
+			l.await(result, step, func(reason interface{}) error {
+				return step(rejection{reason})
+			})
+		}
+		return nil
+	}
+	return promise, step(nil)
+}
+
+// run keeps going until there are no microtasks or timers left. Microtasks
+// are always drained before the next timer fires. Once everything has run
+// the first failure of an async function that nothing handled is returned
+func (l *EventLoop) run(interpreter *Interpreter) error {
+	for {
+		for len(l.microtasks) > 0 {
+			task := l.microtasks[0]
+			l.microtasks = l.microtasks[1:]
+			if err := task.callback(task.value); err != nil {
+				return err
+			}
+		}
+
+		if l.timers.Len() == 0 {
+			return l.unhandledFailure()
+		}
+		t := heap.Pop(&l.timers).(*timer)
+		if _, ok := l.active[t.id]; !ok {
+			continue
+		}
+
+		if l.virtual {
+			l.elapsed = max(l.elapsed, t.due)
+		} else if wait := t.due - l.now(); wait > 0 {
+			time.Sleep(time.Duration(wait * float64(time.Millisecond)))
+		}
+
+		if t.interval > 0 {
+			l.push(t, t.due+t.interval)
+		} else {
+			delete(l.active, t.id)
+		}
+		if _, err := t.callback.call(interpreter, nil); err != nil {
+			return err
+		}
+	}
+}
+
+// unhandledFailure returns the error that rejected the first promise
+// nothing subscribed to
+func (l *EventLoop) unhandledFailure() error {
+	for _, promise := range l.failures {
+		if !promise.handled {
+			return promise.failure
+		}
+	}
+	return nil
+}
+
+// timerQueue orders timers by when they are due, breaking ties by the order
+// they were scheduled in
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+
+func (q timerQueue) Less(a, b int) bool {
+	if q[a].due != q[b].due {
+		return q[a].due < q[b].due
+	}
+	return q[a].order < q[b].order
+}
+
+func (q timerQueue) Swap(a, b int) { q[a], q[b] = q[b], q[a] }
+
+func (q *timerQueue) Push(x any) { *q = append(*q, x.(*timer)) }
+
+func (q *timerQueue) Pop() any {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return t
+}
+
+// eventLoopOf returns the event loop of the interpreter or an error if it is
+// running a spawned task
+func eventLoopOf(interpreter *Interpreter) (*EventLoop, error) {
+	if interpreter.loop == nil {
+		return nil, nativeError("Timers, promises and async functions can't be used from a spawned task.")
+	}
+	return interpreter.loop, nil
+}
+
+// setTimeout(fn, ms) calls fn once after ms milliseconds and returns an id
+// that can be passed to clearTimeout
+func setTimeoutNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	callback, ok := arguments[0].(Callable)
+	if !ok || !acceptsArguments(callback, 0) {
+		return nil, nativeError("setTimeout() expects a function that takes no arguments.")
+	}
+	delay, ok := arguments[1].(float64)
+	if !ok || delay < 0 {
+		return nil, nativeError("Delay must be a non-negative number.")
+	}
+	return float64(loop.schedule(callback, delay, 0)), nil
+}
+
+// setInterval(fn, ms) calls fn every ms milliseconds until the id it returns
+// is passed to clearInterval
+func setIntervalNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	callback, ok := arguments[0].(Callable)
+	if !ok || !acceptsArguments(callback, 0) {
+		return nil, nativeError("setInterval() expects a function that takes no arguments.")
+	}
+	interval, ok := arguments[1].(float64)
+	if !ok || interval <= 0 {
+		return nil, nativeError("Interval must be a positive number.")
+	}
+	return float64(loop.schedule(callback, interval, interval)), nil
+}
+
+// clearTimeout(id) and clearInterval(id) stop a timer from firing again
+func clearTimerNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := arguments[0].(float64)
+	if !ok {
+		return nil, nativeError("Timer id must be a number.")
+	}
+	loop.cancel(int(id))
+	return nil, nil
+}
+
+// promise(executor) creates a promise and calls executor with a function
+// that resolves it, and a function that rejects it if executor takes two
+// arguments
+func promiseNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	executor, ok := arguments[0].(Callable)
+	if !ok || !(acceptsArguments(executor, 1) || acceptsArguments(executor, 2)) {
+		return nil, nativeError("promise() expects a function that takes one or two arguments.")
+	}
+
+	promise := NewLoxPromise(loop)
+	resolve := &NativeFunction{"resolve", 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+		promise.resolve(arguments[0])
+		return nil, nil
+	}}
+	reject := &NativeFunction{"reject", 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+		promise.reject(arguments[0])
+		return nil, nil
+	}}
+	if acceptsArguments(executor, 2) {
+		_, err = executor.call(interpreter, []interface{}{resolve, reject})
+	} else {
+		_, err = executor.call(interpreter, []interface{}{resolve})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return promise, nil
+}
+
+// delay(ms) returns a promise that is resolved with nil after ms milliseconds
+func delayNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	delay, ok := arguments[0].(float64)
+	if !ok || delay < 0 {
+		return nil, nativeError("Delay must be a non-negative number.")
+	}
+
+	promise := NewLoxPromise(loop)
+	resolve := &NativeFunction{"resolve", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+		promise.resolve(nil)
+		return nil, nil
+	}}
+	loop.schedule(resolve, delay, 0)
+	return promise, nil
+}
+
+// all(values) returns a promise for a list of the values once every promise
+// among them has been resolved. It is rejected as soon as one of them is
+func allNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	loop, err := eventLoopOf(interpreter)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		return nil, nativeError("all() expects a list.")
+	}
+
+	promise := NewLoxPromise(loop)
+	results := make([]interface{}, len(list.elements))
+	remaining := len(results)
+	if remaining == 0 {
+		promise.resolve(NewLoxList(results))
+	}
+	for index, element := range list.snapshot() {
+		loop.await(element, func(value interface{}) error {
+			results[index] = value
+			remaining--
+			if remaining == 0 {
+				promise.resolve(NewLoxList(results))
+			}
+			return nil
+		}, func(reason interface{}) error {
+			var failure *RuntimeError
+			if element, ok := element.(*LoxPromise); ok {
+				failure = element.failure
+			}
+			promise.rejectWith(reason, failure)
+			return nil
+		})
+	}
+	return promise, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// runVirtual runs source with a virtual clock and returns what it printed
+// to stdout and stderr
+func runVirtual(t *testing.T, source string) (string, string) {
+	t.Helper()
+	virtualClock = true
+	defer func() { virtualClock = false }()
+	return runScript(t, source)
+}
+
+func TestTimersFireInOrder(t *testing.T) {
+	output, errors := runVirtual(t, `
+fun late() { print format("late {}", clock()); }
+fun early() { print format("early {}", clock()); }
+fun alsoEarly() { print format("also early {}", clock()); }
+fun never() { print "never"; }
+setTimeout(late, 30);
+setTimeout(early, 10);
+setTimeout(alsoEarly, 10);
+clearTimeout(setTimeout(never, 5));
+
+var ticks = 0;
+var id;
+fun tick() {
+  ticks = ticks + 1;
+  print format("tick {}", clock());
+  if (ticks == 3) clearInterval(id);
+}
+id = setInterval(tick, 8);
+print "sync";
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output,
+		"sync",
+		"tick 8",
+		"early 10",
+		"also early 10",
+		"tick 16",
+		"tick 24",
+		"late 30",
+	)
+}
+
+func TestPromiseResolves(t *testing.T) {
+	output, errors := runVirtual(t, `
+fun later(resolve) {
+  fun fire() { resolve(5); }
+  setTimeout(fire, 10);
+}
+fun double(value) { print value; return value * 2; }
+fun show(value) { print value; }
+fun unreachable(reason) { print "unreachable"; }
+promise(later).then(double).catch(unreachable).then(show);
+all([delay(20), promise(later), 3]).then(show);
+print "sync";
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "sync", "5", "10", "[nil, 5, 3]")
+}
+
+func TestPromiseRejects(t *testing.T) {
+	output, errors := runVirtual(t, `
+fun fail(resolve, reject) { reject("boom"); resolve("ignored"); }
+fun unreachable(value) { print "unreachable"; }
+fun recover(reason) { print "caught " + reason; return 1; }
+fun show(value) { print value; }
+promise(fail).then(unreachable).catch(recover).then(show);
+all([delay(10), promise(fail)]).catch(show);
+print "sync";
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "sync", "caught boom", "boom", "1")
+}
+
+func TestAwait(t *testing.T) {
+	output, errors := runVirtual(t, `
+async fun add(a, b) {
+  await delay(10);
+  return a + b;
+}
+async fun main() {
+  var sum = await add(1, 2);
+  print format("{} {}", clock(), sum);
+}
+fun show(value) { print value; }
+main().then(show);
+print "sync";
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "sync", "10 3", "nil")
+}
+
+func TestAwaitRejectedPromise(t *testing.T) {
+	output, errors := runVirtual(t, `
+fun failLater(resolve, reject) {
+  fun fire() { reject("nope"); }
+  setTimeout(fire, 10);
+}
+async fun main() {
+  print "before";
+  await promise(failLater);
+  print "after";
+}
+main();
+`)
+	expectLines(t, output, "before")
+	if !hadRuntimeError {
+		t.Error("expected awaiting a rejected promise to be a runtime error")
+	}
+	if !strings.Contains(errors, "Awaited promise was rejected: nope") {
+		t.Errorf("unexpected error: %q", errors)
+	}
+}
+
+func TestAsyncErrorRejectsPromise(t *testing.T) {
+	output, errors := runVirtual(t, `
+async fun boom() { return nil + 1; }
+async fun caller() {
+  await boom();
+  print "unreachable";
+}
+fun handle(reason) { print "caught " + reason; }
+boom().catch(handle);
+caller().catch(handle);
+print "after";
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output,
+		"after",
+		"caught Operands must be two numbers or two strings.",
+		"caught Awaited promise was rejected: Operands must be two numbers or two strings.",
+	)
+}
+
+func TestUnhandledAsyncErrorIsReported(t *testing.T) {
+	output, errors := runVirtual(t, `
+async fun boom() { return nil + 1; }
+fun show(value) { print value; }
+boom().then(show);
+print "after";
+`)
+	expectLines(t, output, "after")
+	if !hadRuntimeError {
+		t.Error("expected an unhandled async error to be a runtime error")
+	}
+	if !strings.Contains(errors, "[line 2]") {
+		t.Errorf("expected the error to point at the async function, got %q", errors)
+	}
+}
@@ -6,17 +6,30 @@ type Expr interface {
 
 type ExprVisitor interface {
 	VisitAssignExpr(expr *Assign) (interface{}, error)
+	VisitAwaitExpr(expr *Await) (interface{}, error)
 	VisitBinaryExpr(expr *Binary) (interface{}, error)
 	VisitCallExpr(expr *Call) (interface{}, error)
+	VisitCompoundExpr(expr *Compound) (interface{}, error)
+	VisitConditionalExpr(expr *Conditional) (interface{}, error)
+	VisitDestructureExpr(expr *Destructure) (interface{}, error)
 	VisitGetExpr(expr *Get) (interface{}, error)
 	VisitGroupingExpr(expr *Grouping) (interface{}, error)
+	VisitIndexExpr(expr *Index) (interface{}, error)
+	VisitIndexSetExpr(expr *IndexSet) (interface{}, error)
+	VisitListExpr(expr *List) (interface{}, error)
 	VisitLiteralExpr(expr *Literal) (interface{}, error)
 	VisitLogicalExpr(expr *Logical) (interface{}, error)
+	VisitMapExpr(expr *Map) (interface{}, error)
+	VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error)
+	VisitRangeExpr(expr *Range) (interface{}, error)
 	VisitSetExpr(expr *Set) (interface{}, error)
+	VisitSpawnExpr(expr *Spawn) (interface{}, error)
 	VisitSuperExpr(expr *Super) (interface{}, error)
 	VisitThisExpr(expr *This) (interface{}, error)
 	VisitUnaryExpr(expr *Unary) (interface{}, error)
+	VisitUpdateExpr(expr *Update) (interface{}, error)
 	VisitVariableExpr(expr *Variable) (interface{}, error)
+	VisitYieldExpr(expr *Yield) (interface{}, error)
 }
 
 type Assign struct {
@@ -28,6 +41,15 @@ func (a *Assign) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitAssignExpr(a)
 }
 
+type Await struct {
+	keyword Token
+	value   Expr
+}
+
+func (a *Await) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitAwaitExpr(a)
+}
+
 type Binary struct {
 	left     Expr
 	operator Token
@@ -42,15 +64,47 @@ type Call struct {
 	callee    Expr
 	paren     Token
 	arguments []Expr
+	named     []NamedArgument
 }
 
 func (c *Call) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitCallExpr(c)
 }
 
+type Compound struct {
+	target   Expr
+	operator Token
+	value    Expr
+}
+
+func (c *Compound) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitCompoundExpr(c)
+}
+
+type Conditional struct {
+	condition  Expr
+	thenBranch Expr
+	elseBranch Expr
+}
+
+func (c *Conditional) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitConditionalExpr(c)
+}
+
+type Destructure struct {
+	pattern Pattern
+	equals  Token
+	value   Expr
+}
+
+func (d *Destructure) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitDestructureExpr(d)
+}
+
 type Get struct {
-	object Expr
-	name   Token
+	object   Expr
+	name     Token
+	optional bool
 }
 
 func (g *Get) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -65,6 +119,36 @@ func (g *Grouping) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitGroupingExpr(g)
 }
 
+type Index struct {
+	object  Expr
+	bracket Token
+	index   Expr
+}
+
+func (i *Index) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexExpr(i)
+}
+
+type IndexSet struct {
+	object  Expr
+	bracket Token
+	index   Expr
+	value   Expr
+}
+
+func (i *IndexSet) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexSetExpr(i)
+}
+
+type List struct {
+	bracket  Token
+	elements []Expr
+}
+
+func (l *List) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitListExpr(l)
+}
+
 type Literal struct {
 	value interface{}
 }
@@ -83,6 +167,34 @@ func (l *Logical) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitLogicalExpr(l)
 }
 
+type Map struct {
+	brace  Token
+	keys   []Expr
+	values []Expr
+}
+
+func (m *Map) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitMapExpr(m)
+}
+
+type OptionalChain struct {
+	expression Expr
+}
+
+func (o *OptionalChain) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitOptionalChainExpr(o)
+}
+
+type Range struct {
+	start    Expr
+	operator Token
+	end      Expr
+}
+
+func (r *Range) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitRangeExpr(r)
+}
+
 type Set struct {
 	object Expr
 	name   Token
@@ -93,6 +205,15 @@ func (s *Set) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitSetExpr(s)
 }
 
+type Spawn struct {
+	keyword Token
+	call    *Call
+}
+
+func (s *Spawn) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSpawnExpr(s)
+}
+
 type Super struct {
 	keyword Token
 	method  Token
@@ -119,6 +240,16 @@ func (u *Unary) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitUnaryExpr(u)
 }
 
+type Update struct {
+	target   Expr
+	operator Token
+	prefix   bool
+}
+
+func (u *Update) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitUpdateExpr(u)
+}
+
 type Variable struct {
 	name Token
 }
@@ -126,3 +257,12 @@ type Variable struct {
 func (v *Variable) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitVariableExpr(v)
 }
+
+type Yield struct {
+	keyword Token
+	value   Expr
+}
+
+func (y *Yield) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitYieldExpr(y)
+}
@@ -1,22 +1,46 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
 )
 
+// errShortCircuit is returned by an optional property access on nil and is
+// caught by the enclosing OptionalChain so the whole chain evaluates to nil
+var errShortCircuit = errors.New("short circuit")
+
 type Interpreter struct {
 	environment *Envionment
 	globals     *Envionment
 	locals      map[Expr]int
+	// the generator whose body this interpreter is running, if any
+	generator *LoxGenerator
+	// spawned tasks that are still running, shared by every copy of the
+	// interpreter so that the program can wait for them to finish
+	tasks *sync.WaitGroup
+	// the event loop that runs timers and promise callbacks, which is nil in
+	// spawned tasks
+	loop *EventLoop
+	// in strict mode instances can only be given fields that their class
+	// declares
+	strict bool
 }
 
 func NewInterpreter() Interpreter {
 	globals := NewEnvironment(nil)
 	globals.define("clock", clock{})
+	for _, native := range natives {
+		globals.define(native.name, native)
+	}
 	return Interpreter{
 		globals:     globals,
 		environment: globals,
 		locals:      make(map[Expr]int),
+		tasks:       &sync.WaitGroup{},
+		loop:        NewEventLoop(),
 	}
 }
 
@@ -39,6 +63,11 @@ func (i *Interpreter) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 		if i.IsTruthy(left) {
 			return left, nil
 		}
+	} else if expr.operator.tokenType == QUESTION_QUESTION {
+		// only fall back to the right operand when the left is nil
+		if left != nil {
+			return left, nil
+		}
 	} else {
 		// we are in an AND condition
 		if !i.IsTruthy(left) {
@@ -50,16 +79,15 @@ func (i *Interpreter) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 }
 
 // VisitSetExpr will evaluate the object whos property is being set and check
-// to see if its a LoxInstance. If not, thats a runtime error. Otherwise,
-// we evaluate the value being set and store it on the instance.
+// to see if its a LoxInstance or a LoxClass. If not, thats a runtime error.
+// Otherwise, we evaluate the value being set and store it on the object.
 func (i *Interpreter) VisitSetExpr(expr *Set) (interface{}, error) {
 	object, err := i.evaluate(expr.object)
 	if err != nil {
 		return nil, err
 	}
 
-	instance, ok := object.(*LoxInstance)
-	if !ok {
+	if !i.hasFields(object) {
 		return nil, &RuntimeError{expr.name, "Only instances have fields."}
 	}
 
@@ -67,8 +95,7 @@ func (i *Interpreter) VisitSetExpr(expr *Set) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	instance.set(expr.name, value)
-	return value, nil
+	return value, i.setProperty(object, expr.name, value)
 }
 
 func (i *Interpreter) VisitThisExpr(expr *This) (interface{}, error) {
@@ -79,20 +106,59 @@ func (i *Interpreter) VisitSuperExpr(expr *Super) (interface{}, error) {
 	// look up the surrounding class's superclass up by looking up super
 	// in the correct envionment
 	distance := i.locals[expr]
-	superclass := i.environment.getAt(distance, "super").(*LoxClass)
+	superclass, ok := i.environment.getAt(distance, "super").(*LoxClass)
+	if !ok || superclass == nil {
+		// only possible inside a trait mixed into a class without a superclass
+		return nil, &RuntimeError{expr.keyword, "Can't use 'super' in a class with no superclass."}
+	}
 
 	// retrieve the current instance of ("this") by looking it up in the environment.
-	// since "super" is stored one level higher in the environment chain,
-	// we offset the lookup by one to access "this" from the inner environment.
-	object := i.environment.getAt(distance-1, "this").(*LoxInstance)
+	// since "super" is stored two levels higher in the environment chain,
+	// above the environment holding the class, we offset the lookup by two
+	// to access "this" from the inner environment.
+	object := i.environment.getAt(distance-2, "this")
 
-	// find the method on teh superclass
+	// find the method on teh superclass. Inside a class method "this" is
+	// the class itself so we look on the superclass's metaclass instead
 	method, prs := superclass.findMethod(expr.method.lexeme)
+	if _, ok := object.(*LoxClass); ok {
+		method, prs = superclass.metaclass.findMethod(expr.method.lexeme)
+	}
 	if !prs {
 		return nil, &RuntimeError{expr.method, "Undefined property '" + expr.method.lexeme + "'."}
 	}
 
-	return method.bind(object), nil
+	// decorated methods reached through super aren't kept on the instance
+	// since a subclass can decorate a method with the same name differently
+	bound := method.bind(object)
+	if bound.decorators != nil {
+		return i.decorateMethod(nil, bound, expr.method)
+	}
+	return bound, nil
+}
+
+// VisitConditionalExpr will evaluate only one of the branches depending on
+// the truthiness of the condition
+func (i *Interpreter) VisitConditionalExpr(expr *Conditional) (interface{}, error) {
+	condition, err := i.evaluate(expr.condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.IsTruthy(condition) {
+		return i.evaluate(expr.thenBranch)
+	}
+	return i.evaluate(expr.elseBranch)
+}
+
+// VisitOptionalChainExpr will evaluate a chain of calls and property accesses
+// containing "?.", turning a short-circuit anywhere along it into nil
+func (i *Interpreter) VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error) {
+	value, err := i.evaluate(expr.expression)
+	if errors.Is(err, errShortCircuit) {
+		return nil, nil
+	}
+	return value, err
 }
 
 // VisitGroupingExpr will evaluate the expression inside the grouping
@@ -107,6 +173,13 @@ func (i *Interpreter) VisitUnaryExpr(expr *Unary) (interface{}, error) {
 		return nil, err
 	}
 
+	if method, prs := unaryMethods[expr.operator.tokenType]; prs {
+		result, overloaded, err := i.callSpecialMethod(expr.operator, right, method)
+		if overloaded {
+			return result, err
+		}
+	}
+
 	switch expr.operator.tokenType {
 	case BANG:
 		return !i.IsTruthy(right), nil
@@ -116,6 +189,12 @@ func (i *Interpreter) VisitUnaryExpr(expr *Unary) (interface{}, error) {
 			return nil, err
 		}
 		return -right.(float64), err
+	case TILDE:
+		operand, err := i.checkIntegerOperand(expr.operator, right)
+		if err != nil {
+			return nil, err
+		}
+		return float64(^operand), nil
 	}
 
 	return nil, nil
@@ -132,172 +211,1213 @@ func (i *Interpreter) VisitBinaryExpr(expr *Binary) (interface{}, error) {
 		return nil, err
 	}
 
-	switch expr.operator.tokenType {
+	return i.binary(expr.operator, left, right)
+}
+
+// binary will apply a binary operator to two already evaluated operands
+func (i *Interpreter) binary(operator Token, left interface{}, right interface{}) (interface{}, error) {
+	result, overloaded, err := i.overloadedBinary(operator, left, right)
+	if overloaded {
+		return result, err
+	}
+
+	switch operator.tokenType {
 	case MINUS:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) - right.(float64), nil
+	case SLASH:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) / right.(float64), nil
+	case STAR:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) * right.(float64), nil
+	case PERCENT:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return math.Mod(left.(float64), right.(float64)), nil
+	case STAR_STAR:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return math.Pow(left.(float64), right.(float64)), nil
+	case AMPERSAND, PIPE, CARET, LESS_LESS, GREATER_GREATER:
+		return i.bitwise(operator, left, right)
+	case PLUS:
+		if l, ok := left.(float64); ok {
+			if r, ok := right.(float64); ok {
+				return l + r, nil
+			}
+		}
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+		return nil, &RuntimeError{operator, "Operands must be two numbers or two strings."}
+	case GREATER:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) > right.(float64), nil
+	case GREATER_EQUAL:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) >= right.(float64), nil
+	case LESS:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) < right.(float64), nil
+	case LESS_EQUAL:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return left.(float64) <= right.(float64), nil
+	case BANG_EQUAL:
+		equal, err := i.equal(operator, left, right)
+		return !equal, err
+	case EQUAL_EQUAL:
+		return i.equal(operator, left, right)
+	}
+
+	return nil, nil
+}
+
+// binaryMethods maps each overloadable binary operator to the special method
+// an instance on the left hand side can define to implement it
+var binaryMethods = map[TokenType]string{
+	PLUS:            "__add__",
+	MINUS:           "__sub__",
+	STAR:            "__mul__",
+	SLASH:           "__div__",
+	PERCENT:         "__mod__",
+	STAR_STAR:       "__pow__",
+	AMPERSAND:       "__and__",
+	PIPE:            "__or__",
+	CARET:           "__xor__",
+	LESS_LESS:       "__lshift__",
+	GREATER_GREATER: "__rshift__",
+	LESS:            "__lt__",
+	LESS_EQUAL:      "__le__",
+	GREATER:         "__gt__",
+	GREATER_EQUAL:   "__ge__",
+}
+
+// reflectedMethods maps each comparison to the special method that is tried
+// on the right hand side when the left hand side doesn't overload it, so
+// that 1 < v can be answered by v.__gt__(1)
+var reflectedMethods = map[TokenType]string{
+	LESS:          "__gt__",
+	LESS_EQUAL:    "__ge__",
+	GREATER:       "__lt__",
+	GREATER_EQUAL: "__le__",
+}
+
+// unaryMethods maps each overloadable unary operator to its special method
+var unaryMethods = map[TokenType]string{
+	MINUS: "__neg__",
+	TILDE: "__invert__",
+}
+
+// overloadedBinary dispatches a binary operator to a special method if one
+// of the operands is an instance that defines it. The returned bool reports
+// whether the operator was overloaded
+func (i *Interpreter) overloadedBinary(operator Token, left interface{}, right interface{}) (interface{}, bool, error) {
+	if method, prs := binaryMethods[operator.tokenType]; prs {
+		result, overloaded, err := i.callSpecialMethod(operator, left, method, right)
+		if overloaded {
+			return result, true, err
+		}
+	}
+	if method, prs := reflectedMethods[operator.tokenType]; prs {
+		return i.callSpecialMethod(operator, right, method, left)
+	}
+	return nil, false, nil
+}
+
+// callSpecialMethod calls the named method on the object if the object is
+// an instance whose class defines it. The returned bool reports whether the
+// method was found
+func (i *Interpreter) callSpecialMethod(operator Token, object interface{}, name string, arguments ...interface{}) (interface{}, bool, error) {
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, false, nil
+	}
+	method, prs := instance.class.findMethod(name)
+	if !prs {
+		return nil, false, nil
+	}
+	if !acceptsArguments(method, len(arguments)) {
+		return nil, true, &RuntimeError{operator, fmt.Sprintf("Special method '%s' must take %d parameters.", name, len(arguments))}
+	}
+	result, err := method.bind(instance).call(i, arguments)
+	return result, true, err
+}
+
+// equal compares two values for equality, letting instances decide with an
+// "__eq__" method. The left operand is asked first, then the right
+func (i *Interpreter) equal(operator Token, left interface{}, right interface{}) (bool, error) {
+	result, overloaded, err := i.callSpecialMethod(operator, left, "__eq__", right)
+	if !overloaded {
+		result, overloaded, err = i.callSpecialMethod(operator, right, "__eq__", left)
+	}
+	if err != nil {
+		return false, err
+	}
+	if overloaded {
+		return i.IsTruthy(result), nil
+	}
+	return i.isEqual(left, right), nil
+}
+
+// bitwise will evaluate one of the bitwise or shift operators. These only make
+// sense on whole numbers so both operands are converted to integers first
+func (i *Interpreter) bitwise(operator Token, left interface{}, right interface{}) (interface{}, error) {
+	l, r, err := i.checkIntegerOperands(operator, left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator.tokenType {
+	case AMPERSAND:
+		return float64(l & r), nil
+	case PIPE:
+		return float64(l | r), nil
+	case CARET:
+		return float64(l ^ r), nil
+	case LESS_LESS, GREATER_GREATER:
+		if r < 0 {
+			return nil, &RuntimeError{operator, "Shift count must not be negative."}
+		}
+		if operator.tokenType == LESS_LESS {
+			return float64(l << r), nil
+		}
+		return float64(l >> r), nil
+	}
+
+	return nil, nil
+}
+
+func (i *Interpreter) VisitCallExpr(expr *Call) (interface{}, error) {
+	callee, err := i.evaluate(expr.callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, err := i.evaluateArguments(expr, callee)
+	if err != nil {
+		return nil, err
+	}
+	function, err := i.checkCall(expr.paren, callee, arguments)
+	if err != nil {
+		return nil, err
+	}
+	result, err := function.call(i, arguments)
+	if runtimeError, ok := err.(*RuntimeError); ok && runtimeError.token.line == 0 {
+		// errors raised by natives have no location of their own
+		runtimeError.token = expr.paren
+	}
+	return result, err
+}
+
+// checkCall makes sure the callee can be called with the given arguments
+func (i *Interpreter) checkCall(paren Token, callee interface{}, arguments []interface{}) (Callable, error) {
+	function, ok := callee.(Callable)
+	if !ok {
+		return nil, &RuntimeError{paren, "Can only call functions and classes."}
+	}
+	if !acceptsArguments(function, len(arguments)) {
+		min, max := function.arity()
+		return nil, &RuntimeError{paren, "Expected " +
+			describeArity(min, max) +
+			" arguments but got " +
+			fmt.Sprintf("%d", len(arguments)) + "."}
+	}
+	return function, nil
+}
+
+// evaluateArguments evaluates the arguments of a call in order. Named
+// arguments are put in the position of the parameter they name, and any
+// parameters skipped over are filled with missingArgument
+func (i *Interpreter) evaluateArguments(expr *Call, callee interface{}) ([]interface{}, error) {
+	var arguments []interface{}
+	for _, argument := range expr.arguments {
+		arg, err := i.evaluate(argument)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, arg)
+	}
+	if len(expr.named) == 0 {
+		return arguments, nil
+	}
+
+	function, ok := callee.(interface{ parameters() []Parameter })
+	if !ok {
+		return nil, &RuntimeError{expr.paren, "Only functions and classes declared in Lox take named arguments."}
+	}
+	parameters := function.parameters()
+	for _, argument := range expr.named {
+		position := -1
+		for index, parameter := range parameters {
+			if parameter.name.lexeme == argument.name.lexeme && !parameter.rest {
+				position = index
+			}
+		}
+		if position == -1 {
+			return nil, &RuntimeError{argument.name, "No parameter named '" + argument.name.lexeme + "'."}
+		}
+
+		for len(arguments) <= position {
+			arguments = append(arguments, missingArgument{})
+		}
+		if arguments[position] != (missingArgument{}) {
+			return nil, &RuntimeError{argument.name, "Got more than one value for parameter '" + argument.name.lexeme + "'."}
+		}
+
+		value, err := i.evaluate(argument.value)
+		if err != nil {
+			return nil, err
+		}
+		arguments[position] = value
+	}
+	return arguments, nil
+}
+
+// VisitSpawnExpr will evaluate the callee and arguments of the call and then
+// run it as a separate task on its own goroutine. The result is a channel
+// that receives the call's return value when it finishes, or is closed
+// without a value if the task fails with a runtime error
+func (i *Interpreter) VisitSpawnExpr(expr *Spawn) (interface{}, error) {
+	callee, err := i.evaluate(expr.call.callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, err := i.evaluateArguments(expr.call, callee)
+	if err != nil {
+		return nil, err
+	}
+	function, err := i.checkCall(expr.call.paren, callee, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	task := *i
+	task.generator = nil
+	task.loop = nil
+	result := NewLoxChannel(1)
+	i.tasks.Add(1)
+	go func() {
+		defer i.tasks.Done()
+		defer result.close()
+		value, err := function.call(&task, arguments)
+		if err != nil {
+			if runtimeError, ok := err.(*RuntimeError); ok {
+				if runtimeError.token.line == 0 {
+					runtimeError.token = expr.call.paren
+				}
+				reportRuntimeError(*runtimeError)
+			}
+			return
+		}
+		result.send(value)
+	}()
+	return result, nil
+}
+
+// VisitGetExpr will evaluate the expression whos property is being accessed
+// In Lox, only objects such as instances and classes have properties. If the
+// object is some other type like a number, inboking a getter is a runtime
+// error
+func (i *Interpreter) VisitGetExpr(expr *Get) (interface{}, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.optional && object == nil {
+		return nil, errShortCircuit
+	}
+
+	return i.getProperty(object, expr.name)
+}
+
+// getProperty reads a property from an object. If the property is a getter
+// it is called and its result is returned in place of the method
+func (i *Interpreter) getProperty(object interface{}, name Token) (interface{}, error) {
+	if name.tokenType == PRIVATE_NAME {
+		return i.getPrivate(object, name)
+	}
+	loxObject, ok := object.(LoxObject)
+	if !ok {
+		return nil, &RuntimeError{name, "Only instances have properties."}
+	}
+	value, err := loxObject.get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if method, ok := value.(LoxFunction); ok && method.declaration.getter {
+		return method.call(i, nil)
+	}
+	if method, ok := value.(LoxFunction); ok && method.decorators != nil {
+		return i.decorateMethod(object, method, name)
+	}
+	return value, nil
+}
+
+// setProperty stores a field on an object
+func (i *Interpreter) setProperty(object interface{}, name Token, value interface{}) error {
+	if name.tokenType == PRIVATE_NAME {
+		return i.setPrivate(object, name, value)
+	}
+	if instance, ok := object.(*LoxInstance); ok && i.strict && !instance.class.declaresField(name.lexeme) {
+		return &RuntimeError{name, "Can't set undeclared field '" + name.lexeme + "' on an instance of '" + instance.class.name + "'."}
+	}
+	if loxObject, ok := object.(LoxObject); ok {
+		return loxObject.set(name, value)
+	}
+	return &RuntimeError{name, "Only instances have fields."}
+}
+
+// privateAccess finds the class whose methods are running, which is held
+// in their closure, and checks that the object is an instance of it. Only
+// the class's own methods can reach its private members
+func (i *Interpreter) privateAccess(object interface{}, name Token) (*LoxClass, *LoxInstance, error) {
+	value, err := i.environment.get(Token{IDENTIFIER, "#class", nil, name.line})
+	class, ok := value.(*LoxClass)
+	if err != nil || !ok {
+		return nil, nil, &RuntimeError{name, "Can't access private member '" + name.lexeme + "' outside of a class."}
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok || !isInstanceOf(instance, class) {
+		return nil, nil, &RuntimeError{name, "Can't access private member '" + name.lexeme + "' of an object that isn't an instance of '" + class.name + "'."}
+	}
+	return class, instance, nil
+}
+
+// getPrivate reads a private field or method of the class whose methods
+// are running. Private methods aren't inherited
+func (i *Interpreter) getPrivate(object interface{}, name Token) (interface{}, error) {
+	class, instance, err := i.privateAccess(object, name)
+	if err != nil {
+		return nil, err
+	}
+	if value, prs := instance.privateField(privateName{class, name.lexeme}); prs {
+		return value, nil
+	}
+	if method, prs := class.privateMethods[name.lexeme]; prs {
+		bound := method.bind(instance)
+		if bound.declaration.getter {
+			return bound.call(i, nil)
+		}
+		if bound.decorators != nil {
+			return i.decorateMethod(instance, bound, name)
+		}
+		return bound, nil
+	}
+	return nil, &RuntimeError{name, "Undefined private member '" + name.lexeme + "'."}
+}
+
+// setPrivate stores a private field for the class whose methods are running
+func (i *Interpreter) setPrivate(object interface{}, name Token, value interface{}) error {
+	class, instance, err := i.privateAccess(object, name)
+	if err != nil {
+		return err
+	}
+	if i.strict && !declaresPrivateField(class, name.lexeme) {
+		return &RuntimeError{name, "Can't set undeclared field '" + name.lexeme + "' on an instance of '" + class.name + "'."}
+	}
+	return instance.setPrivateField(privateName{class, name.lexeme}, name, value)
+}
+
+// declaresPrivateField returns true if the private field is declared in the
+// body of the class. Private fields aren't inherited so superclasses aren't
+// checked
+func declaresPrivateField(class *LoxClass, name string) bool {
+	for _, field := range class.declaredFields {
+		if field.name.lexeme == name {
+			return true
+		}
+	}
+	return false
+}
+
+// initializeFields gives a new instance the fields declared in the bodies
+// of its class and superclasses. The furthest superclass goes first so that
+// a subclass can declare a field again with a different initial value
+func (i *Interpreter) initializeFields(class *LoxClass, instance *LoxInstance) error {
+	if class.superclass != nil {
+		if err := i.initializeFields(class.superclass, instance); err != nil {
+			return err
+		}
+	}
+	if len(class.declaredFields) == 0 {
+		return nil
+	}
+
+	environment := NewEnvironment(class.fieldEnvironment)
+	environment.define("this", instance)
+	previous := i.environment
+	i.environment = environment
+	defer func() { i.environment = previous }()
+
+	for _, field := range class.declaredFields {
+		var value interface{}
+		if field.initializer != nil {
+			var err error
+			value, err = i.evaluate(field.initializer)
+			if err != nil {
+				return err
+			}
+		}
+		if err := i.setProperty(instance, field.name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasFields returns true if the object can have fields stored on it
+func (i *Interpreter) hasFields(object interface{}) bool {
+	_, ok := object.(LoxObject)
+	return ok
+}
+
+// VisitVarStmt will evaluate the variable statement
+// and define the variable in the current environment
+// if there is an initializer, it will evaluate the initializer
+func (i *Interpreter) VisitVarStmt(stmt *Var) (interface{}, error) {
+	var value interface{}
+	var err error
+	if stmt.initializer != nil {
+		value, err = i.evaluate(stmt.initializer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.constant {
+		i.environment.defineConstant(stmt.name.lexeme, value)
+	} else {
+		i.environment.define(stmt.name.lexeme, value)
+	}
+	return nil, nil
+}
+
+// VisitDestructureVarStmt will evaluate the initializer and define a
+// variable for every name in the pattern
+func (i *Interpreter) VisitDestructureVarStmt(stmt *DestructureVar) (interface{}, error) {
+	value, err := i.evaluate(stmt.initializer)
+	if err != nil {
+		return nil, err
+	}
+	return nil, i.destructure(stmt.pattern, value, func(target Pattern, value interface{}) error {
+		name := target.(*NamePattern).name.lexeme
+		if stmt.constant {
+			i.environment.defineConstant(name, value)
+		} else {
+			i.environment.define(name, value)
+		}
+		return nil
+	})
+}
+
+// VisitDestructureExpr will evaluate the right hand side and then store its
+// parts into each of the targets from left to right
+func (i *Interpreter) VisitDestructureExpr(expr *Destructure) (interface{}, error) {
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+	err = i.destructure(expr.pattern, value, func(target Pattern, value interface{}) error {
+		return i.assignTarget(target.(*TargetPattern).target, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// destructure takes value apart according to the pattern, calling bind with
+// each name or target pattern and the part of the value that goes there
+func (i *Interpreter) destructure(pattern Pattern, value interface{}, bind func(Pattern, interface{}) error) error {
+	switch pattern := pattern.(type) {
+	case *ListPattern:
+		list, ok := value.(*LoxList)
+		if !ok {
+			return &RuntimeError{pattern.bracket, "Can only destructure lists with a list pattern."}
+		}
+		for index, element := range pattern.elements {
+			var part interface{}
+			if index < len(list.elements) {
+				part = list.element(index)
+			}
+			if err := i.destructureElement(element.target, element.defaultValue, part, bind); err != nil {
+				return err
+			}
+		}
+		if pattern.rest != nil {
+			var rest []interface{}
+			if len(pattern.elements) < len(list.elements) {
+				rest = append(rest, list.snapshot()[len(pattern.elements):]...)
+			}
+			return bind(&NamePattern{*pattern.rest}, NewLoxList(rest))
+		}
+		return nil
+	case *ObjectPattern:
+		for _, property := range pattern.properties {
+			part, err := i.destructureProperty(pattern.brace, value, property)
+			if err != nil {
+				return err
+			}
+			if err := i.destructureElement(property.target, property.defaultValue, part, bind); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return bind(pattern, value)
+}
+
+// destructureElement uses the default value in place of nil before taking
+// the part apart with the target pattern
+func (i *Interpreter) destructureElement(target Pattern, defaultValue Expr, part interface{}, bind func(Pattern, interface{}) error) error {
+	if part == nil && defaultValue != nil {
+		var err error
+		part, err = i.evaluate(defaultValue)
+		if err != nil {
+			return err
+		}
+	}
+	return i.destructure(target, part, bind)
+}
+
+// destructureProperty reads one property of an object pattern from an
+// instance or a map. A missing property is nil when it has a default
+func (i *Interpreter) destructureProperty(brace Token, value interface{}, property PropertyPattern) (interface{}, error) {
+	switch value := value.(type) {
+	case *LoxMap:
+		part, _ := value.lookup(property.name.lexeme)
+		return part, nil
+	case *LoxInstance:
+		_, isField := value.field(property.name.lexeme)
+		_, isMethod := value.class.findMethod(property.name.lexeme)
+		if !isField && !isMethod && property.defaultValue != nil {
+			return nil, nil
+		}
+		return i.getProperty(value, property.name)
+	}
+	if _, ok := value.(LoxObject); ok {
+		return i.getProperty(value, property.name)
+	}
+	return nil, &RuntimeError{brace, "Can only destructure instances and maps with an object pattern."}
+}
+
+// assignTarget stores a value into a variable, property or index expression
+func (i *Interpreter) assignTarget(target Expr, value interface{}) error {
+	switch target := target.(type) {
+	case *Variable:
+		return i.assignVariable(target.name, target, value)
+	case *Get:
+		object, err := i.evaluate(target.object)
+		if err != nil {
+			return err
+		}
+		return i.setProperty(object, target.name, value)
+	case *Index:
+		object, err := i.evaluate(target.object)
+		if err != nil {
+			return err
+		}
+		index, err := i.evaluate(target.index)
+		if err != nil {
+			return err
+		}
+		return i.setIndex(target.bracket, object, index, value)
+	}
+	return nil
+}
+
+// VisitMatchStmt will run the body of the first case with a pattern that
+// matches the subject and a guard that is true. The names bound by the
+// pattern are defined in a fresh environment for the guard and body
+func (i *Interpreter) VisitMatchStmt(stmt *Match) (interface{}, error) {
+	subject, err := i.evaluate(stmt.subject)
+	if err != nil {
+		return nil, err
+	}
+	for _, matchCase := range stmt.cases {
+		for _, pattern := range matchCase.patterns {
+			environment := NewEnvironment(i.environment)
+			matched, err := i.matchPattern(matchCase.keyword, pattern, subject, environment)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+			if matchCase.guard != nil {
+				guard, err := i.evaluateIn(matchCase.guard, environment)
+				if err != nil {
+					return nil, err
+				}
+				if !i.IsTruthy(guard) {
+					continue
+				}
+			}
+			return nil, i.executeBlock([]Stmt{matchCase.body}, environment)
+		}
+	}
+	return nil, nil
+}
+
+// matchPattern returns true if value matches the pattern, defining the
+// names the pattern binds in environment as it goes
+func (i *Interpreter) matchPattern(keyword Token, pattern Pattern, value interface{}, environment *Envionment) (bool, error) {
+	switch pattern := pattern.(type) {
+	case *WildcardPattern:
+		return true, nil
+	case *NamePattern:
+		environment.define(pattern.name.lexeme, value)
+		return true, nil
+	case *ValuePattern:
+		expected, err := i.evaluateIn(pattern.value, environment)
+		if err != nil {
+			return false, err
+		}
+		switch expected := expected.(type) {
+		case *LoxRange:
+			n, ok := value.(float64)
+			return ok && expected.contains(n), nil
+		case *LoxClass:
+			return isInstanceOf(value, expected), nil
+		}
+		return i.equal(keyword, value, expected)
+	case *ClassPattern:
+		class, err := i.evaluateIn(pattern.class, environment)
+		if err != nil {
+			return false, err
+		}
+		target, ok := class.(*LoxClass)
+		if !ok {
+			return false, &RuntimeError{pattern.class.name, "Can only match instances of a class."}
+		}
+		if !isInstanceOf(value, target) {
+			return false, nil
+		}
+		return i.matchPattern(keyword, pattern.object, value, environment)
+	case *ListPattern:
+		list, ok := value.(*LoxList)
+		if !ok || len(list.elements) < len(pattern.elements) {
+			return false, nil
+		}
+		if pattern.rest == nil && len(list.elements) != len(pattern.elements) {
+			return false, nil
+		}
+		for index, element := range pattern.elements {
+			matched, err := i.matchPattern(keyword, element.target, list.element(index), environment)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		if pattern.rest != nil {
+			var rest []interface{}
+			rest = append(rest, list.snapshot()[len(pattern.elements):]...)
+			environment.define(pattern.rest.lexeme, NewLoxList(rest))
+		}
+		return true, nil
+	case *ObjectPattern:
+		for _, property := range pattern.properties {
+			part, found, err := i.matchProperty(value, property.name)
+			if err != nil || !found {
+				return false, err
+			}
+			matched, err := i.matchPattern(keyword, property.target, part, environment)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// matchProperty reads a property for an object pattern in a match case.
+// Values without the property don't match rather than being an error
+func (i *Interpreter) matchProperty(value interface{}, name Token) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case *LoxMap:
+		part, found := value.lookup(name.lexeme)
+		return part, found, nil
+	case *LoxInstance:
+		_, isField := value.field(name.lexeme)
+		_, isMethod := value.class.findMethod(name.lexeme)
+		if !isField && !isMethod {
+			return nil, false, nil
+		}
+		part, err := i.getProperty(value, name)
+		return part, true, err
+	}
+	return nil, false, nil
+}
+
+// VisitWhileStmt will execute the while loop
+// executing the statement body until the condition is no longer true
+func (i *Interpreter) VisitWhileStmt(stmt *While) (interface{}, error) {
+	value, err := i.evaluate(stmt.condition)
+	if err != nil {
+		return nil, err
+	}
+	for i.IsTruthy(value) {
+		err := i.execute(stmt.body)
+		if err != nil {
+			return nil, err
+		}
+		value, err = i.evaluate(stmt.condition)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// VisitForInStmt will execute the body once for every value produced by the
+// iterable, binding each one to the loop variable in a fresh environment
+func (i *Interpreter) VisitForInStmt(stmt *ForIn) (interface{}, error) {
+	iterable, err := i.evaluate(stmt.iterable)
+	if err != nil {
+		return nil, err
+	}
+	return nil, i.iterate(stmt.keyword, iterable, func(value interface{}) error {
+		environment := NewEnvironment(i.environment)
+		environment.define(stmt.name.lexeme, value)
+		return i.executeBlock([]Stmt{stmt.body}, environment)
+	})
+}
+
+// iterate calls each with every value of a list, the keys of a map, the
+// characters of a string or the values of a range, sequence or generator. Instances are
+// iterated by calling their iterator() method and iterating over the result,
+// or by calling next() until it returns nil
+func (i *Interpreter) iterate(keyword Token, iterable interface{}, each func(interface{}) error) error {
+	switch iterable := iterable.(type) {
+	case *LoxList:
+		for index := 0; index < len(iterable.elements); index++ {
+			if err := each(iterable.element(index)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *LoxMap:
+		// iterate over a copy so the body can add keys to the map
+		keys, _ := iterable.entries()
+		for _, key := range keys {
+			if err := each(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case string:
+		for index := 0; index < len(iterable); index++ {
+			if err := each(string(iterable[index])); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *LoxRange:
+		for index := 0; index < iterable.length(); index++ {
+			if err := each(iterable.at(index)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *LoxSequence:
+		return i.iterate(keyword, iterable.source, func(value interface{}) error {
+			result, err := iterable.function.call(i, []interface{}{value})
+			if err != nil {
+				return err
+			}
+			if !iterable.filter {
+				return each(result)
+			}
+			if i.IsTruthy(result) {
+				return each(value)
+			}
+			return nil
+		})
+	case *LoxClass:
+		if iterable.members == nil {
+			break
+		}
+		for _, member := range iterable.members {
+			if err := each(member); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *LoxGenerator:
+		for {
+			value, ok, err := iterable.next()
+			if err != nil || !ok {
+				return err
+			}
+			if err := each(value); err != nil {
+				return err
+			}
+		}
+	case *LoxInstance:
+		if method, prs := iterable.class.findMethod("iterator"); prs && acceptsArguments(method, 0) {
+			iterator, err := method.bind(iterable).call(i, nil)
+			if err != nil {
+				return err
+			}
+			// an iterator() that returns the instance itself falls through to
+			// calling its next() method below
+			if iterator != iterable {
+				return i.iterate(keyword, iterator, each)
+			}
+		}
+		if method, prs := iterable.class.findMethod("next"); prs && acceptsArguments(method, 0) {
+			next := method.bind(iterable)
+			for {
+				value, err := next.call(i, nil)
+				if err != nil || value == nil {
+					return err
+				}
+				if err := each(value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return &RuntimeError{keyword, "Can only iterate over lists, maps, strings, ranges, generators, enums and iterators."}
+}
+
+// VisitAssignExpr will evaluate the assignment expression
+// and assign the value to the variable in the current environment
+// if the variable is not defined in the current environment
+// it will throw a runtime error
+func (i *Interpreter) VisitAssignExpr(expr *Assign) (interface{}, error) {
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.assignVariable(expr.name, expr, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// assignVariable stores the value in the variable that the resolver bound
+// to the expression, falling back to the globals if it was not resolved
+func (i *Interpreter) assignVariable(name Token, expr Expr, value interface{}) error {
+	distance, found := i.locals[expr]
+	if found {
+		i.environment.assignAt(distance, name, value)
+		return nil
+	}
+	return i.globals.assign(name, value)
+}
+
+// VisitCompoundExpr will evaluate a compound assignment such as "x += 1" by
+// applying the arithmetic operator to the current value of the target and
+// the right hand side, then storing the result back into the target
+func (i *Interpreter) VisitCompoundExpr(expr *Compound) (interface{}, error) {
+	operator := expr.operator
+	switch operator.tokenType {
+	case PLUS_EQUAL:
+		operator.tokenType = PLUS
+	case MINUS_EQUAL:
+		operator.tokenType = MINUS
+	case STAR_EQUAL:
+		operator.tokenType = STAR
+	case SLASH_EQUAL:
+		operator.tokenType = SLASH
+	case PERCENT_EQUAL:
+		operator.tokenType = PERCENT
+	}
+
+	_, result, err := i.modify(expr.target, func(current interface{}) (interface{}, error) {
+		value, err := i.evaluate(expr.value)
+		if err != nil {
+			return nil, err
+		}
+		return i.binary(operator, current, value)
+	})
+	return result, err
+}
+
+// VisitUpdateExpr will evaluate an increment or decrement. The prefix form
+// results in the updated value while the postfix form results in the value
+// the target held before it was updated
+func (i *Interpreter) VisitUpdateExpr(expr *Update) (interface{}, error) {
+	previous, result, err := i.modify(expr.target, func(current interface{}) (interface{}, error) {
+		err := i.checkNumberOperand(expr.operator, current)
+		if err != nil {
+			return nil, err
+		}
+		if expr.operator.tokenType == PLUS_PLUS {
+			return current.(float64) + 1, nil
+		}
+		return current.(float64) - 1, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.prefix {
+		return result, nil
+	}
+	return previous, nil
+}
+
+// modify reads the current value of an assignment target, computes its new
+// value with update and stores it back. Any sub-expressions of the target,
+// such as the object of a property access, are only evaluated once
+func (i *Interpreter) modify(target Expr, update func(interface{}) (interface{}, error)) (interface{}, interface{}, error) {
+	switch target := target.(type) {
+	case *Variable:
+		current, err := i.lookUpVariable(target.name, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := update(current)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return left.(float64) - right.(float64), nil
-	case SLASH:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		return current, value, i.assignVariable(target.name, target, value)
+	case *Get:
+		object, err := i.evaluate(target.object)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return left.(float64) / right.(float64), nil
-	case STAR:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		if !i.hasFields(object) {
+			return nil, nil, &RuntimeError{target.name, "Only instances have fields."}
+		}
+		current, err := i.getProperty(object, target.name)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return left.(float64) * right.(float64), nil
-	case PLUS:
-		if l, ok := left.(float64); ok {
-			if r, ok := right.(float64); ok {
-				return l + r, nil
-			}
+		value, err := update(current)
+		if err != nil {
+			return nil, nil, err
 		}
-		if l, ok := left.(string); ok {
-			if r, ok := right.(string); ok {
-				return l + r, nil
-			}
+		return current, value, i.setProperty(object, target.name, value)
+	case *Index:
+		object, err := i.evaluate(target.object)
+		if err != nil {
+			return nil, nil, err
 		}
-		return nil, &RuntimeError{expr.operator, "Operands must be two numbers or two strings."}
-	case GREATER:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		index, err := i.evaluate(target.index)
+		if err != nil {
+			return nil, nil, err
+		}
+		current, err := i.getIndex(target.bracket, object, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := update(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		return current, value, i.setIndex(target.bracket, object, index, value)
+	}
+	return nil, nil, nil
+}
+
+// VisitListExpr will evaluate each of the elements in order and collect
+// them into a new list
+func (i *Interpreter) VisitListExpr(expr *List) (interface{}, error) {
+	var elements []interface{}
+	for _, element := range expr.elements {
+		value, err := i.evaluate(element)
 		if err != nil {
 			return nil, err
 		}
-		return left.(float64) > right.(float64), nil
-	case GREATER_EQUAL:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		elements = append(elements, value)
+	}
+	return NewLoxList(elements), nil
+}
+
+// VisitMapExpr will evaluate each key and value in order and collect them
+// into a new map
+func (i *Interpreter) VisitMapExpr(expr *Map) (interface{}, error) {
+	result := NewLoxMap()
+	for index := range expr.keys {
+		key, err := i.evaluate(expr.keys[index])
 		if err != nil {
 			return nil, err
 		}
-		return left.(float64) >= right.(float64), nil
-	case LESS:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		value, err := i.evaluate(expr.values[index])
 		if err != nil {
 			return nil, err
 		}
-		return left.(float64) < right.(float64), nil
-	case LESS_EQUAL:
-		err := i.checkNumberOperands(expr.operator, left, right)
+		err = result.set(expr.brace, key, value)
 		if err != nil {
 			return nil, err
 		}
-		return left.(float64) <= right.(float64), nil
-	case BANG_EQUAL:
-		return !i.isEqual(left, right), nil
-	case EQUAL_EQUAL:
-		return i.isEqual(left, right), nil
 	}
-
-	return nil, nil
+	return result, nil
 }
 
-func (i *Interpreter) VisitCallExpr(expr *Call) (interface{}, error) {
-	callee, err := i.evaluate(expr.callee)
+// VisitRangeExpr will evaluate both bounds and create a lazy range over them
+func (i *Interpreter) VisitRangeExpr(expr *Range) (interface{}, error) {
+	start, err := i.evaluate(expr.start)
 	if err != nil {
 		return nil, err
 	}
-
-	var arguments []interface{}
-	for _, argument := range expr.arguments {
-		arg, err := i.evaluate(argument)
-		if err != nil {
-			return nil, err
-		}
-		arguments = append(arguments, arg)
+	end, err := i.evaluate(expr.end)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, ok := callee.(Callable); !ok {
-		return nil, &RuntimeError{expr.paren, "Can only call functions and classes."}
-	}
-	function := callee.(Callable)
-	if len(arguments) != function.arity() {
-		return nil, &RuntimeError{expr.paren, "Expected " +
-			fmt.Sprintf("%d", function.arity()) +
-			" arguments but got " +
-			fmt.Sprintf("%d", len(arguments)) + "."}
+	startNum, ok := start.(float64)
+	endNum, ok2 := end.(float64)
+	if !ok || !ok2 || math.IsInf(startNum, 0) || math.IsInf(endNum, 0) {
+		return nil, &RuntimeError{expr.operator, "Range bounds must be finite numbers."}
 	}
-	return function.call(i, arguments)
+	return NewLoxRange(startNum, endNum, expr.operator.tokenType == DOT_DOT), nil
 }
 
-// VisitGetExpr will evaluate the expression whos property is being accessed
-// In Lox, only instances of classes have properties. If the object is some
-// other type like a number, inboking a getter is a runtime error
-func (i *Interpreter) VisitGetExpr(expr *Get) (interface{}, error) {
-	object, err := i.evaluate(expr.object)
+// VisitAwaitExpr pauses the async function until the awaited promise is
+// resolved and evaluates to its value. Awaiting something that isn't a
+// promise still lets the event loop run before carrying on with the value,
+// and awaiting a promise that is rejected is a runtime error
+func (i *Interpreter) VisitAwaitExpr(expr *Await) (interface{}, error) {
+	value, err := i.evaluate(expr.value)
 	if err != nil {
 		return nil, err
 	}
-
-	if instance, ok := object.(*LoxInstance); ok {
-		return instance.get(expr.name)
+	if i.generator == nil {
+		return nil, &RuntimeError{expr.keyword, "Can't await outside of an async function."}
 	}
-
-	return nil, &RuntimeError{expr.name, "Only instances have properties."}
+	value = i.generator.yield(value)
+	if rejected, ok := value.(rejection); ok {
+		reason, err := i.stringify(rejected.reason)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &RuntimeError{expr.keyword, "Awaited promise was rejected: " + reason}
+	}
+	return value, nil
 }
 
-// VisitVarStmt will evaluate the variable statement
-// and define the variable in the current environment
-// if there is an initializer, it will evaluate the initializer
-func (i *Interpreter) VisitVarStmt(stmt *Var) (interface{}, error) {
+// VisitYieldExpr hands a value back to whoever called next() on the
+// generator and pauses until it is asked for another one
+func (i *Interpreter) VisitYieldExpr(expr *Yield) (interface{}, error) {
 	var value interface{}
 	var err error
-	if stmt.initializer != nil {
-		value, err = i.evaluate(stmt.initializer)
+	if expr.value != nil {
+		value, err = i.evaluate(expr.value)
 		if err != nil {
-			return err, nil
+			return nil, err
 		}
 	}
 
-	i.environment.define(stmt.name.lexeme, value)
+	if i.generator == nil {
+		return nil, &RuntimeError{expr.keyword, "Can't yield outside of a generator."}
+	}
+	i.generator.yield(value)
 	return nil, nil
 }
 
-// VisitWhileStmt will execute the while loop
-// executing the statement body until the condition is no longer true
-func (i *Interpreter) VisitWhileStmt(stmt *While) (interface{}, error) {
-	value, err := i.evaluate(stmt.condition)
+// VisitIndexExpr will evaluate a subscript such as "list[0]"
+func (i *Interpreter) VisitIndexExpr(expr *Index) (interface{}, error) {
+	object, err := i.evaluate(expr.object)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
-	for i.IsTruthy(value) {
-		err := i.execute(stmt.body)
-		if err != nil {
-			return err, nil
-		}
-		value, err = i.evaluate(stmt.condition)
-		if err != nil {
-			return err, nil
-		}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return i.getIndex(expr.bracket, object, index)
 }
 
-// VisitAssignExpr will evaluate the assignment expression
-// and assign the value to the variable in the current environment
-// if the variable is not defined in the current environment
-// it will throw a runtime error
-func (i *Interpreter) VisitAssignExpr(expr *Assign) (interface{}, error) {
+// VisitIndexSetExpr will evaluate an assignment to a subscript such as
+// "list[0] = value"
+func (i *Interpreter) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
+	}
 	value, err := i.evaluate(expr.value)
 	if err != nil {
 		return nil, err
 	}
+	err = i.setIndex(expr.bracket, object, index, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
 
-	distance, found := i.locals[expr]
-	if found {
-		i.environment.assignAt(distance, expr.name, value)
-	} else {
-		err := i.globals.assign(expr.name, value)
-		if err != nil {
-			return nil, err
+// getIndex looks up the element at index in a list, the value under a key in
+// a map or the character at index in a string. Instances can support indexing with an "__index__" method
+func (i *Interpreter) getIndex(bracket Token, object interface{}, index interface{}) (interface{}, error) {
+	result, overloaded, err := i.callSpecialMethod(bracket, object, "__index__", index)
+	if overloaded {
+		return result, err
+	}
+
+	switch object := object.(type) {
+	case *LoxList:
+		return object.get(bracket, index)
+	case *LoxMap:
+		return object.get(bracket, index)
+	case string:
+		num, ok := index.(float64)
+		if !ok || !isInteger(num) {
+			return nil, &RuntimeError{bracket, "String index must be an integer."}
+		}
+		if num < 0 || int(num) >= len(object) {
+			return nil, &RuntimeError{bracket, "String index out of range."}
 		}
+		return string(object[int(num)]), nil
 	}
+	return nil, &RuntimeError{bracket, "Only lists, maps and strings can be indexed."}
+}
 
-	return value, nil
+// setIndex stores a value at index in a list or map or calls "__setindex__" on an
+// instance. Strings are immutable so they can't be assigned through an index
+func (i *Interpreter) setIndex(bracket Token, object interface{}, index interface{}, value interface{}) error {
+	_, overloaded, err := i.callSpecialMethod(bracket, object, "__setindex__", index, value)
+	if overloaded {
+		return err
+	}
+
+	switch object := object.(type) {
+	case *LoxList:
+		return object.set(bracket, index, value)
+	case *LoxMap:
+		return object.set(bracket, index, value)
+	}
+	return &RuntimeError{bracket, "Only lists and maps support index assignment."}
 }
 
 // VisitVariableExpr will evaluate the variable expression
@@ -319,13 +1439,27 @@ func (i *Interpreter) lookUpVariable(name Token, expr Expr) (interface{}, error)
 // and return the value of the expression
 func (i *Interpreter) VisitExpressionStmt(stmt *Expression) (interface{}, error) {
 	_, err := i.evaluate(stmt.expression)
-	return err, nil
+	return nil, err
 }
 
 // VisitFunctionStmt will define the function in the current environment
 func (i *Interpreter) VisitFunctionStmt(stmt *Function) (interface{}, error) {
-	function := &LoxFunction{*stmt, i.environment, false}
-	i.environment.define(stmt.name.lexeme, function)
+	function := &LoxFunction{*stmt, i.environment, false, nil, nil}
+	if stmt.decorators == nil {
+		i.environment.define(stmt.name.lexeme, function)
+		return nil, nil
+	}
+
+	annotations, decorators, err := i.evaluateDecorators(stmt.decorators)
+	if err != nil {
+		return nil, err
+	}
+	function.annotations = annotations
+	value, err := i.applyDecorators(function, decorators, stmt.name)
+	if err != nil {
+		return nil, err
+	}
+	i.environment.define(stmt.name.lexeme, value)
 	return nil, nil
 }
 
@@ -358,9 +1492,13 @@ func (i *Interpreter) VisitIfStmt(stmt *If) (interface{}, error) {
 func (i *Interpreter) VisitPrintStmt(stmt *Print) (interface{}, error) {
 	value, err := i.evaluate(stmt.expression)
 	if err != nil {
-		return err, nil
+		return nil, err
+	}
+	text, err := i.stringify(value)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println(i.stringify(value))
+	fmt.Println(text)
 	return nil, nil
 }
 
@@ -374,7 +1512,7 @@ func (i *Interpreter) VisitReturnStmt(stmt *Return) (interface{}, error) {
 	if stmt.value != nil {
 		value, err = i.evaluate(stmt.value)
 		if err != nil {
-			return err, nil
+			return nil, err
 		}
 	}
 	return nil, &ReturnException{value}
@@ -382,10 +1520,16 @@ func (i *Interpreter) VisitReturnStmt(stmt *Return) (interface{}, error) {
 
 // VisitBlockStmt will evaluate the block statement
 func (i *Interpreter) VisitBlockStmt(stmt *Block) (interface{}, error) {
-	return i.executeBlock(stmt.statements, NewEnvironment(i.environment)), nil
+	return nil, i.executeBlock(stmt.statements, NewEnvironment(i.environment))
 }
 
 func (i *Interpreter) VisitClassStmt(stmt *Class) (interface{}, error) {
+	// decorators are evaluated before anything else in the declaration
+	annotations, decorators, err := i.evaluateDecorators(stmt.decorators)
+	if err != nil {
+		return nil, err
+	}
+
 	// if the class has a superclass expression, we evaluate it.
 	// since that could potentially evaluate to some other kind of object,
 	// we have to check at runtime that the thing we want to be a superclass
@@ -394,13 +1538,29 @@ func (i *Interpreter) VisitClassStmt(stmt *Class) (interface{}, error) {
 	if stmt.superclass != nil {
 		superclassCandidate, err := i.evaluate(stmt.superclass)
 		if err != nil {
-			return err, nil
+			return nil, err
+		}
+		superclassValue, ok := superclassCandidate.(*LoxClass)
+		if !ok {
+			return nil, &RuntimeError{stmt.superclass.name, "Superclass must be a class"}
+		}
+		if superclassValue.record {
+			return nil, &RuntimeError{stmt.superclass.name, "Can't inherit from record '" + superclassValue.name + "'."}
+		}
+		superclass = superclassValue
+	}
+
+	var traits []*LoxTrait
+	for _, traitExpr := range stmt.traits {
+		traitCandidate, err := i.evaluate(traitExpr)
+		if err != nil {
+			return nil, err
 		}
-		superclassValue, ok := superclassCandidate.(LoxClass)
+		trait, ok := traitCandidate.(*LoxTrait)
 		if !ok {
-			return &RuntimeError{stmt.superclass.name, "Superclass must be a class"}, nil
+			return nil, &RuntimeError{traitExpr.name, "Can only mix in traits."}
 		}
-		superclass = &superclassValue
+		traits = append(traits, trait)
 	}
 
 	i.environment.define(stmt.name.lexeme, nil)
@@ -414,13 +1574,58 @@ func (i *Interpreter) VisitClassStmt(stmt *Class) (interface{}, error) {
 		i.environment.define("super", superclass)
 	}
 
-	var methods map[string]LoxFunction = make(map[string]LoxFunction)
+	methods, err := i.traitMethods(stmt, traits, superclass)
+	if err != nil {
+		return nil, err
+	}
+	// the methods are closures over an environment holding the class itself,
+	// which is how private members know which class they are used from
+	classEnvironment := NewEnvironment(i.environment)
+	privateMethods := make(map[string]LoxFunction)
 	for _, method := range stmt.methods {
-		function := LoxFunction{method, i.environment, method.name.lexeme == "init"}
-		methods[method.name.lexeme] = function
+		function := LoxFunction{method, classEnvironment, method.name.lexeme == "init", nil, nil}
+		function.annotations, function.decorators, err = i.evaluateDecorators(method.decorators)
+		if err != nil {
+			return nil, err
+		}
+		if method.name.tokenType == PRIVATE_NAME {
+			privateMethods[method.name.lexeme] = function
+		} else {
+			methods[method.name.lexeme] = function
+		}
+	}
+
+	var classMethods map[string]LoxFunction = make(map[string]LoxFunction)
+	classDecorators := make(map[string][]Callable)
+	for _, method := range stmt.classMethods {
+		function := LoxFunction{method, classEnvironment, false, nil, nil}
+		function.annotations, classDecorators[method.name.lexeme], err = i.evaluateDecorators(method.decorators)
+		if err != nil {
+			return nil, err
+		}
+		classMethods[method.name.lexeme] = function
 	}
 
-	var class LoxClass = LoxClass{stmt.name.lexeme, superclass, methods}
+	var class *LoxClass = NewLoxClass(stmt.name.lexeme, superclass, methods, classMethods)
+	class.privateMethods = privateMethods
+	class.record = stmt.record
+	class.annotations = annotations
+	class.declaredFields = stmt.fields
+	class.fieldEnvironment = classEnvironment
+	classEnvironment.define("#class", class)
+
+	// a decorated class method is bound to the class straight away and the
+	// result is stored as a static field, which is found before the method
+	for _, method := range stmt.classMethods {
+		if decorators := classDecorators[method.name.lexeme]; decorators != nil {
+			function := classMethods[method.name.lexeme]
+			decorated, err := i.applyDecorators(function.bind(class), decorators, method.name)
+			if err != nil {
+				return nil, err
+			}
+			class.fields[method.name.lexeme] = decorated
+		}
+	}
 
 	// now that the methods have been created we pop the envionment defining
 	// the superclass
@@ -428,7 +1633,118 @@ func (i *Interpreter) VisitClassStmt(stmt *Class) (interface{}, error) {
 		i.environment = i.environment.enclosing
 	}
 
+	// the interfaces are checked against the finished class so that methods
+	// from the superclass and traits count towards conformance
+	for index, interfaceExpr := range stmt.interfaces {
+		interfaceCandidate, err := i.evaluate(interfaceExpr)
+		if err != nil {
+			return nil, err
+		}
+		iface, ok := interfaceCandidate.(*LoxInterface)
+		if !ok {
+			return nil, &RuntimeError{stmt.interfaces[index].name, "Can only implement interfaces."}
+		}
+		err = iface.checkConformance(class, stmt.interfaces[index].name)
+		if err != nil {
+			return nil, err
+		}
+		class.interfaces = append(class.interfaces, iface)
+	}
+
 	i.environment.assign(stmt.name, class)
+
+	// static fields are initialized once the class has been bound to its
+	// name so that their initializers can refer to the class itself
+	for _, field := range stmt.classFields {
+		var value interface{}
+		if field.initializer != nil {
+			var err error
+			value, err = i.evaluate(field.initializer)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := class.set(field.name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if decorators != nil {
+		value, err := i.applyDecorators(class, decorators, stmt.name)
+		if err != nil {
+			return nil, err
+		}
+		i.environment.assign(stmt.name, value)
+	}
+	return nil, nil
+}
+
+// VisitEnumStmt creates a class for the enum along with one frozen
+// instance of it for every member, in the order they were declared
+func (i *Interpreter) VisitEnumStmt(stmt *Enum) (interface{}, error) {
+	classEnvironment := NewEnvironment(i.environment)
+	methods := make(map[string]LoxFunction)
+	for _, method := range stmt.methods {
+		methods[method.name.lexeme] = LoxFunction{method, classEnvironment, false, nil, nil}
+	}
+	class := NewLoxClass(stmt.name.lexeme, nil, methods, make(map[string]LoxFunction))
+	classEnvironment.define("#class", class)
+	class.members = make([]*LoxInstance, 0, len(stmt.members))
+	for ordinal, member := range stmt.members {
+		instance := NewLoxInstance(class)
+		instance.fields["name"] = member.lexeme
+		instance.fields["ordinal"] = float64(ordinal)
+		instance.frozen = true
+		class.fields[member.lexeme] = instance
+		class.members = append(class.members, instance)
+	}
+	i.environment.define(stmt.name.lexeme, class)
+	return nil, nil
+}
+
+// traitMethods creates the functions for every method mixed into the class
+// by its traits. Each trait's methods close over a new environment defining
+// "super" as the class's superclass. If two traits provide a method with the
+// same name the class must override it, otherwise the class is rejected
+func (i *Interpreter) traitMethods(stmt *Class, traits []*LoxTrait, superclass *LoxClass) (map[string]LoxFunction, error) {
+	var overridden map[string]bool = make(map[string]bool)
+	for _, method := range stmt.methods {
+		overridden[method.name.lexeme] = true
+	}
+
+	var methods map[string]LoxFunction = make(map[string]LoxFunction)
+	var providers map[string]*LoxTrait = make(map[string]*LoxTrait)
+	for index, trait := range traits {
+		environment := NewEnvironment(trait.closure)
+		environment.define("super", superclass)
+		// trait methods have no class of their own to keep private members in
+		environment = NewEnvironment(environment)
+		environment.define("#class", nil)
+		for _, method := range trait.methods {
+			name := method.name.lexeme
+			if provider, prs := providers[name]; prs && !overridden[name] {
+				return nil, &RuntimeError{stmt.traits[index].name, "Method '" + name +
+					"' is provided by both '" + provider.name + "' and '" + trait.name +
+					"'. Class '" + stmt.name.lexeme + "' must override it."}
+			}
+			providers[name] = trait
+			methods[name] = LoxFunction{method, environment, name == "init", nil, nil}
+		}
+	}
+	return methods, nil
+}
+
+// VisitInterfaceStmt will define the interface in the current environment
+func (i *Interpreter) VisitInterfaceStmt(stmt *Interface) (interface{}, error) {
+	i.environment.define(stmt.name.lexeme, &LoxInterface{stmt.name.lexeme, stmt.methods})
+	return nil, nil
+}
+
+// VisitTraitStmt will define the trait in the current environment. The trait
+// captures the environment so its methods can be closed over it later
+func (i *Interpreter) VisitTraitStmt(stmt *Trait) (interface{}, error) {
+	i.environment.define(stmt.name.lexeme, &LoxTrait{stmt.name.lexeme, stmt.methods, i.environment})
 	return nil, nil
 }
 
@@ -452,10 +1768,16 @@ func (i *Interpreter) interpret(statements []Stmt) {
 	for _, statement := range statements {
 		err := i.execute(statement)
 		if err != nil {
-			reportRuntimeError(*(err.(*RuntimeError)))
+			reportError(err)
 			return
 		}
 	}
+	if err := i.loop.run(i); err != nil {
+		reportError(err)
+		return
+	}
+	// spawned tasks are allowed to finish before the program exits
+	i.tasks.Wait()
 }
 
 func (i *Interpreter) execute(statement Stmt) error {
@@ -467,19 +1789,100 @@ func (i *Interpreter) resolve(expr Expr, depth int) {
 	i.locals[expr] = depth
 }
 
-func (i *Interpreter) stringify(object interface{}) string {
+// stringify converts a value to the text print displays for it. Instances
+// whose class defines a toString() method are displayed using its result
+func (i *Interpreter) stringify(object interface{}) (string, error) {
+	return i.stringifyValue(object, make(map[interface{}]bool))
+}
+
+// stringifyValue does the work of stringify. visiting holds the lists and
+// maps being printed further up, so one that contains itself is printed as
+// "[...]" or "{...}" instead of forever
+func (i *Interpreter) stringifyValue(object interface{}, visiting map[interface{}]bool) (string, error) {
 	if object == nil {
-		return "nil"
+		return "nil", nil
 	}
 
 	if fnum, ok := object.(float64); ok {
-		if fnum == float64(int(fnum)) {
-			return fmt.Sprintf("%.0f", fnum)
-		} else {
-			return fmt.Sprintf("%g", fnum)
+		return formatNumber(fnum), nil
+	}
+
+	if list, ok := object.(*LoxList); ok {
+		if visiting[list] {
+			return "[...]", nil
+		}
+		visiting[list] = true
+		defer delete(visiting, list)
+
+		var sb strings.Builder
+		sb.WriteString("[")
+		for index, element := range list.snapshot() {
+			if index > 0 {
+				sb.WriteString(", ")
+			}
+			text, err := i.stringifyValue(element, visiting)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(text)
+		}
+		sb.WriteString("]")
+		return sb.String(), nil
+	}
+
+	if m, ok := object.(*LoxMap); ok {
+		if visiting[m] {
+			return "{...}", nil
+		}
+		visiting[m] = true
+		defer delete(visiting, m)
+
+		var sb strings.Builder
+		sb.WriteString("{")
+		keys, values := m.entries()
+		for index, key := range keys {
+			if index > 0 {
+				sb.WriteString(", ")
+			}
+			keyText, err := i.stringifyValue(key, visiting)
+			if err != nil {
+				return "", err
+			}
+			valueText, err := i.stringifyValue(values[index], visiting)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(keyText + ": " + valueText)
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	}
+
+	if instance, ok := object.(*LoxInstance); ok {
+		if method, prs := instance.class.findMethod("toString"); prs && acceptsArguments(method, 0) {
+			result, err := method.bind(instance).call(i, nil)
+			if err != nil {
+				return "", err
+			}
+			text, ok := result.(string)
+			if !ok {
+				return "", &RuntimeError{method.declaration.name, "toString() must return a string."}
+			}
+			return text, nil
+		}
+		if instance.class.record {
+			return i.stringifyRecord(instance, visiting)
 		}
 	}
-	return fmt.Sprintf("%v", object)
+	return fmt.Sprintf("%v", object), nil
+}
+
+// formatNumber prints whole numbers without a decimal point
+func formatNumber(fnum float64) string {
+	if fnum == float64(int(fnum)) {
+		return fmt.Sprintf("%.0f", fnum)
+	}
+	return fmt.Sprintf("%g", fnum)
 }
 
 // IsTruthy will return true if the value is not nil or false
@@ -494,10 +1897,18 @@ func (i *Interpreter) IsTruthy(value interface{}) bool {
 }
 
 // isEqual will compare two values and return true if they are equal
-// this currently uses golangs == operator which can be adjusted to
-// match the behaviour of lox
+// following the rules of valuesEqual
 func (i *Interpreter) isEqual(a interface{}, b interface{}) bool {
-	return a == b
+	return valuesEqual(a, b)
+}
+
+// evaluateIn evaluates an expression in the given environment rather than
+// the current one
+func (i *Interpreter) evaluateIn(expr Expr, environment *Envionment) (interface{}, error) {
+	previous := i.environment
+	i.environment = environment
+	defer func() { i.environment = previous }()
+	return i.evaluate(expr)
 }
 
 // Evaluate will evaluate the expression
@@ -522,3 +1933,28 @@ func (i *Interpreter) checkNumberOperands(operator Token, left interface{}, righ
 	}
 	return &RuntimeError{operator, "Operands must be numbers."}
 }
+
+// checkIntegerOperand will check if the operand is a number without a
+// fractional part that fits in an int64 and return it as an integer
+func (i *Interpreter) checkIntegerOperand(operator Token, operand interface{}) (int64, error) {
+	if num, ok := operand.(float64); ok && isInteger(num) {
+		return int64(num), nil
+	}
+	return 0, &RuntimeError{operator, "Operand must be an integer."}
+}
+
+// checkIntegerOperands will check if the operands are both integers
+func (i *Interpreter) checkIntegerOperands(operator Token, left interface{}, right interface{}) (int64, int64, error) {
+	if l, ok := left.(float64); ok && isInteger(l) {
+		if r, ok := right.(float64); ok && isInteger(r) {
+			return int64(l), int64(r), nil
+		}
+	}
+	return 0, 0, &RuntimeError{operator, "Operands must be integers."}
+}
+
+// isInteger returns true if the number has no fractional part and can be
+// represented as an int64 without losing precision
+func isInteger(num float64) bool {
+	return num == math.Trunc(num) && num >= math.MinInt64 && num < math.MaxInt64
+}
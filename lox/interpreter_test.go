@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestPrintingContainersThatContainThemselves(t *testing.T) {
+	output, errors := runScript(t, `
+var l = [1];
+l[0] = l;
+print l;
+var m = {"a": 1};
+m["self"] = m;
+print m;
+var shared = [2];
+print [shared, shared];
+record R(items);
+var items = [nil];
+var r = R(items);
+items[0] = r;
+print r;
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "[[...]]", "{a: 1, self: {...}}", "[[2], [2]]", "R(items: [R(items: [...])])")
+}
+
+func TestStaticFieldsAreInherited(t *testing.T) {
+	output, errors := runScript(t, `
+class Base { class var pi = 3; class var e = 2; }
+class Sub < Base { class var e = 1; }
+print Sub.pi;
+print Sub.e;
+Sub.pi = 4;
+print Sub.pi;
+print Base.pi;
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "3", "1", "4", "3")
+
+	expectCompileError(t, `class A { class var name = "x"; }`, "Can't use 'name' as a static member name.")
+	expectCompileError(t, `class A { class superclass() { return nil; } }`, "Can't use 'superclass' as a static member name.")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LoxChannel passes values between spawned tasks. It is a thin wrapper
+// around a Go channel, so sending blocks until there is a receiver or room in
+// the buffer, and receiving blocks until a value arrives
+type LoxChannel struct {
+	channel chan interface{}
+}
+
+func NewLoxChannel(capacity int) *LoxChannel {
+	return &LoxChannel{make(chan interface{}, capacity)}
+}
+
+func (l *LoxChannel) send(value interface{}) (err error) {
+	defer func() {
+		if recover() != nil {
+			err = nativeError("Can't send on a closed channel.")
+		}
+	}()
+	l.channel <- value
+	return nil
+}
+
+// receive returns the next value sent on the channel, or nil once the
+// channel has been closed and every value sent before that has been received
+func (l *LoxChannel) receive() interface{} {
+	return <-l.channel
+}
+
+func (l *LoxChannel) close() (err error) {
+	defer func() {
+		if recover() != nil {
+			err = nativeError("Channel is already closed.")
+		}
+	}()
+	close(l.channel)
+	return nil
+}
+
+func (l *LoxChannel) get(name Token) (interface{}, error) {
+	switch name.lexeme {
+	case "send":
+		return &NativeFunction{"send", 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return nil, l.send(arguments[0])
+		}}, nil
+	case "receive":
+		return &NativeFunction{"receive", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return l.receive(), nil
+		}}, nil
+	case "close":
+		return &NativeFunction{"close", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return nil, l.close()
+		}}, nil
+	}
+	return nil, &RuntimeError{name, "Undefined property '" + name.lexeme + "'."}
+}
+
+func (l *LoxChannel) set(name Token, value interface{}) error {
+	return &RuntimeError{name, "Can't add properties to a channel."}
+}
+
+func (l *LoxChannel) String() string {
+	return "<channel>"
+}
+
+// maxChannelCapacity limits the buffer of a channel, since the whole buffer
+// is allocated when the channel is made
+const maxChannelCapacity = 1 << 20
+
+// channel(capacity?) creates a channel that can hold capacity values before
+// a send blocks. Without a capacity every send waits for a receiver
+func channelNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if len(arguments) == 0 {
+		return NewLoxChannel(0), nil
+	}
+	capacity, ok := arguments[0].(float64)
+	if !ok || !isInteger(capacity) || capacity < 0 {
+		return nil, nativeError("Channel capacity must be a non-negative integer.")
+	}
+	if capacity > maxChannelCapacity {
+		return nil, nativeError(fmt.Sprintf("Channel capacity can't be more than %d.", maxChannelCapacity))
+	}
+	return NewLoxChannel(int(capacity)), nil
+}
+
+// select(channels) waits until one of a list of channels has a value ready
+// and returns a list of that channel and the value received from it. The
+// value is nil if the channel was closed
+func selectNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, ok := arguments[0].(*LoxList)
+	if !ok || len(list.elements) == 0 {
+		return nil, nativeError("select() expects a non-empty list of channels.")
+	}
+
+	channels := list.snapshot()
+	cases := make([]reflect.SelectCase, len(channels))
+	for index, element := range channels {
+		channel, ok := element.(*LoxChannel)
+		if !ok {
+			return nil, nativeError("select() expects a non-empty list of channels.")
+		}
+		cases[index] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.channel)}
+	}
+
+	chosen, value, received := reflect.Select(cases)
+	var result interface{}
+	if received {
+		result = value.Interface()
+	}
+	return NewLoxList([]interface{}{channels[chosen], result}), nil
+}
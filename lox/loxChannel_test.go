@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannelCapacityIsLimited(t *testing.T) {
+	expectRuntimeError(t, `channel(1e15);`, "Channel capacity can't be more than 1048576.")
+
+	output, errors := runScript(t, `
+var c = channel(1048576);
+c.send(1);
+print c.receive();
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "1")
+}
+
+func TestSpawnedTasksShareObjects(t *testing.T) {
+	output, errors := runScript(t, `
+class Counter {}
+var counter = Counter();
+var list = [0, 0];
+var map = {};
+fun work(id) {
+  for (var n = 0; n < 200; n = n + 1) {
+    setField(counter, format("f{}", n), id);
+    list[n % 2] = id;
+    map[format("{} {}", id, n)] = n;
+    print format("{}", list);
+  }
+  return id;
+}
+var tasks = [spawn work(1), spawn work(2), spawn work(3), spawn work(4)];
+for (task in tasks) task.receive();
+var count = 0;
+for (name in fields(counter)) count = count + 1;
+print count;
+count = 0;
+for (key in map) count = count + 1;
+print count;
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if got := lines[len(lines)-2:]; got[0] != "200" || got[1] != "800" {
+		t.Errorf("unexpected counts: %q", got)
+	}
+}
@@ -1,33 +1,109 @@
 package main
 
+import "sync"
+
+// LoxClass is both the blueprint for its instances and an object in its own
+// right. Every class is an instance of its metaclass, which holds the class
+// methods, and has its own fields for static state
 type LoxClass struct {
+	// guards the static fields, which spawned tasks can share
+	mutex      sync.RWMutex
 	name       string
 	superclass *LoxClass
 	methods    map[string]LoxFunction
+	// private methods are kept apart so that they can't be found by name
+	// from outside the class or through a subclass
+	privateMethods map[string]LoxFunction
+	metaclass      *LoxClass
+	fields         map[string]interface{}
+	interfaces     []*LoxInterface
+	// the members of an enum in declaration order, nil for other classes
+	members []*LoxInstance
+	// records compare by value and can't be changed once created
+	record bool
+	// the decorators of the declaration that aren't functions
+	annotations []interface{}
+	// the instance fields declared in the class body and the environment
+	// their initializers run in
+	declaredFields   []Var
+	fieldEnvironment *Envionment
+}
+
+// NewLoxClass creates a class along with its metaclass. The metaclass
+// inherits from the superclass's metaclass so class methods are inherited
+// the same way as instance methods
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]LoxFunction, classMethods map[string]LoxFunction) *LoxClass {
+	var metasuperclass *LoxClass = nil
+	if superclass != nil {
+		metasuperclass = superclass.metaclass
+	}
+	metaclass := &LoxClass{name: name + " class", superclass: metasuperclass, methods: classMethods, fields: make(map[string]interface{})}
+	return &LoxClass{name: name, superclass: superclass, methods: methods, metaclass: metaclass, fields: make(map[string]interface{})}
 }
 
-func (l LoxClass) String() string {
+func (l *LoxClass) String() string {
 	return l.name
 }
 
-func (l LoxClass) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (l *LoxClass) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if l.members != nil {
+		return nil, nativeError("Can't create new members of enum '" + l.name + "'.")
+	}
 	var instance *LoxInstance = NewLoxInstance(l)
+	err := interpreter.initializeFields(l, instance)
+	if err != nil {
+		return nil, err
+	}
 	initializer, prs := l.findMethod("init")
 	if prs {
-		initializer.bind(instance).call(interpreter, arguments)
+		_, err := initializer.bind(instance).call(interpreter, arguments)
+		if err != nil {
+			return nil, err
+		}
 	}
+	instance.frozen = l.record
 	return instance, nil
 }
 
-func (l LoxClass) arity() int {
+func (l *LoxClass) arity() (int, int) {
 	initializer, prs := l.findMethod("init")
 	if !prs {
-		return 0
+		return 0, 0
 	}
 	return initializer.arity()
 }
 
-func (l LoxClass) findMethod(name string) (*LoxFunction, bool) {
+// parameters returns the parameters of the initializer so that classes can
+// be called with named arguments
+func (l *LoxClass) parameters() []Parameter {
+	initializer, prs := l.findMethod("init")
+	if !prs {
+		return nil
+	}
+	return initializer.parameters()
+}
+
+// declaresField returns true if the field is declared in the body of the
+// class or one of its superclasses, or is one of the fields of a record
+func (l *LoxClass) declaresField(name string) bool {
+	for class := l; class != nil; class = class.superclass {
+		for _, field := range class.declaredFields {
+			if field.name.lexeme == name {
+				return true
+			}
+		}
+		if class.record {
+			for _, component := range class.components() {
+				if component.name.lexeme == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (l *LoxClass) findMethod(name string) (*LoxFunction, bool) {
 	if value, prs := l.methods[name]; prs {
 		return &value, true
 	}
@@ -40,3 +116,78 @@ func (l LoxClass) findMethod(name string) (*LoxFunction, bool) {
 
 	return nil, false
 }
+
+// get looks up a property on the class the same way LoxInstance.get does,
+// using the metaclass in place of the instance's class. The built-in "name"
+// and "superclass" properties allow classes to be inspected
+func (l *LoxClass) get(name Token) (interface{}, error) {
+	switch name.lexeme {
+	case "name":
+		return l.name, nil
+	case "superclass":
+		if l.superclass == nil {
+			return nil, nil
+		}
+		return l.superclass, nil
+	case "values":
+		// an enum's members are copied so the list can be changed freely
+		if l.members != nil {
+			return &NativeFunction{"values", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+				values := make([]interface{}, len(l.members))
+				for index, member := range l.members {
+					values[index] = member
+				}
+				return NewLoxList(values), nil
+			}}, nil
+		}
+	}
+
+	// static fields are inherited like class methods, so a subclass sees
+	// the fields of its superclasses unless it has its own by that name
+	for class := l; class != nil; class = class.superclass {
+		if field, prs := class.field(name.lexeme); prs {
+			return field, nil
+		}
+	}
+
+	if l.metaclass != nil {
+		method, prs := l.metaclass.findMethod(name.lexeme)
+		if prs {
+			return method.bind(l), nil
+		}
+	}
+
+	return nil, &RuntimeError{name, "Undefined property '" + name.lexeme + "'."}
+}
+
+func (l *LoxClass) set(name Token, value interface{}) error {
+	if name.lexeme == "name" || name.lexeme == "superclass" {
+		return &RuntimeError{name, "Can't assign to built-in class property '" + name.lexeme + "'."}
+	}
+	if l.members != nil {
+		return &RuntimeError{name, "Can't assign to a property of enum '" + l.name + "'."}
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.fields[name.lexeme] = value
+	return nil
+}
+
+// field returns the value of a static field stored on the class
+func (l *LoxClass) field(name string) (interface{}, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	value, prs := l.fields[name]
+	return value, prs
+}
+
+// fieldValues returns a copy of the static fields stored on the class
+func (l *LoxClass) fieldValues() map[string]interface{} {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	fields := make(map[string]interface{}, len(l.fields))
+	for name, value := range l.fields {
+		fields[name] = value
+	}
+	return fields
+}
@@ -6,18 +6,60 @@ type LoxFunction struct {
 	declaration   Function
 	closure       *Envionment
 	isInitializer bool
+	// the decorators of the declaration that aren't functions
+	annotations []interface{}
+	// the decorators of a method, which are applied once it has been bound
+	decorators []Callable
 }
 
-func (l *LoxFunction) bind(instance *LoxInstance) LoxFunction {
+// bind creates a copy of the function whose closure defines "this" as the
+// given object, which is an instance for methods and the class itself for
+// class methods
+func (l *LoxFunction) bind(object interface{}) LoxFunction {
 	var environment *Envionment = NewEnvironment(l.closure)
-	environment.define("this", instance)
-	return LoxFunction{l.declaration, environment, l.isInitializer}
+	environment.define("this", object)
+	return LoxFunction{l.declaration, environment, l.isInitializer, l.annotations, l.decorators}
 }
 
 func (l LoxFunction) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
 	var environment = NewEnvironment(l.closure)
 	for i, param := range l.declaration.params {
-		environment.define(param.lexeme, arguments[i])
+		if param.rest {
+			var rest []interface{}
+			if i < len(arguments) {
+				rest = append(rest, arguments[i:]...)
+			}
+			environment.define(param.name.lexeme, NewLoxList(rest))
+			break
+		}
+
+		if i < len(arguments) && arguments[i] != (missingArgument{}) {
+			environment.define(param.name.lexeme, arguments[i])
+		} else if param.defaultValue != nil {
+			// defaults are evaluated on every call and can refer to the
+			// parameters before them
+			value, err := interpreter.evaluateIn(param.defaultValue, environment)
+			if err != nil {
+				return nil, err
+			}
+			environment.define(param.name.lexeme, value)
+		} else {
+			return nil, nativeError("Missing argument for parameter '" + param.name.lexeme + "'.")
+		}
+	}
+
+	if l.declaration.async {
+		loop, err := eventLoopOf(interpreter)
+		if err != nil {
+			return nil, err
+		}
+		return loop.async(NewLoxGenerator(interpreter, l.declaration.body, environment))
+	}
+
+	// calling a generator function doesn't run its body, that happens as
+	// values are asked for from the generator it returns
+	if l.declaration.generator {
+		return NewLoxGenerator(interpreter, l.declaration.body, environment), nil
 	}
 
 	err := interpreter.executeBlock(l.declaration.body, environment)
@@ -38,8 +80,12 @@ func (l LoxFunction) call(interpreter *Interpreter, arguments []interface{}) (in
 	return nil, nil
 }
 
-func (l LoxFunction) arity() int {
-	return len(l.declaration.params)
+func (l LoxFunction) arity() (int, int) {
+	return parameterArity(l.declaration.params)
+}
+
+func (l LoxFunction) parameters() []Parameter {
+	return l.declaration.params
 }
 
 func (l LoxFunction) String() string {
@@ -0,0 +1,109 @@
+package main
+
+import "errors"
+
+// LoxGenerator is returned by calling a function that contains yield. The
+// function's body runs on its own goroutine with its own copy of the
+// interpreter, and hands control back and forth with the caller so that only
+// one of them is ever running. A generator that is abandoned before it
+// finishes leaves its goroutine blocked until the program exits.
+// Async functions are run the same way, pausing at each await instead
+type LoxGenerator struct {
+	interpreter Interpreter
+	body        []Stmt
+	environment *Envionment
+	started     bool
+	done        bool
+	resumes     chan interface{}
+	results     chan generatorResult
+}
+
+// generatorResult is sent from the generator's goroutine each time it yields
+// a value or finishes running, in which case value is what it returned
+type generatorResult struct {
+	value interface{}
+	err   error
+	done  bool
+}
+
+func NewLoxGenerator(interpreter *Interpreter, body []Stmt, environment *Envionment) *LoxGenerator {
+	generator := &LoxGenerator{
+		interpreter: *interpreter,
+		body:        body,
+		environment: environment,
+		resumes:     make(chan interface{}),
+		results:     make(chan generatorResult),
+	}
+	generator.interpreter.generator = generator
+	return generator
+}
+
+// next runs the generator until it yields its next value. Once the body has
+// finished the bool result is false and every later call returns nil
+func (l *LoxGenerator) next() (interface{}, bool, error) {
+	value, finished, err := l.resume(nil)
+	if finished {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// resume runs the body until it next pauses, passing value back as the
+// result of the yield it is paused at. It returns the value the body paused
+// with, or what it returned if finished is true
+func (l *LoxGenerator) resume(value interface{}) (result interface{}, finished bool, err error) {
+	if l.done {
+		return nil, true, nil
+	}
+
+	if !l.started {
+		l.started = true
+		go l.run()
+	} else {
+		l.resumes <- value
+	}
+
+	paused := <-l.results
+	if paused.done {
+		l.done = true
+		return paused.value, true, paused.err
+	}
+	return paused.value, false, nil
+}
+
+func (l *LoxGenerator) run() {
+	var value interface{}
+	err := l.interpreter.executeBlock(l.body, l.environment)
+	if errors.Is(err, &ReturnException{}) {
+		value = err.(*ReturnException).value
+		err = nil
+	}
+	l.results <- generatorResult{value: value, err: err, done: true}
+}
+
+// yield is called on the generator's goroutine to hand a value back to the
+// caller of resume, blocking until the generator is resumed again
+func (l *LoxGenerator) yield(value interface{}) interface{} {
+	l.results <- generatorResult{value: value}
+	return <-l.resumes
+}
+
+// get exposes the next() method that lox code can call directly. It returns
+// nil once the generator is exhausted
+func (l *LoxGenerator) get(name Token) (interface{}, error) {
+	if name.lexeme == "next" {
+		return &NativeFunction{"next", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			value, _, err := l.next()
+			return value, err
+		}}, nil
+	}
+	return nil, &RuntimeError{name, "Undefined property '" + name.lexeme + "'."}
+}
+
+func (l *LoxGenerator) set(name Token, value interface{}) error {
+	return &RuntimeError{name, "Can't add properties to a generator."}
+}
+
+func (l *LoxGenerator) String() string {
+	return "<generator>"
+}
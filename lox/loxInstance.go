@@ -1,16 +1,35 @@
 package main
 
+import "sync"
+
+// LoxInstance is an object created by calling a class. Spawned tasks can
+// share instances, so the mutex guards the fields and the caches below
 type LoxInstance struct {
-	class  LoxClass
+	mutex  sync.RWMutex
+	class  *LoxClass
 	fields map[string]interface{}
+	// frozen instances refuse to have their fields set
+	frozen bool
+	// the methods of the instance that have had their decorators applied
+	decorated map[string]interface{}
+	// private fields are kept apart from the others since they belong to
+	// the class that set them rather than to the instance as a whole
+	private map[privateName]interface{}
+}
+
+// privateName identifies a private field by the class it belongs to, so a
+// class and its subclass can both have a private field of the same name
+type privateName struct {
+	class *LoxClass
+	name  string
 }
 
-func NewLoxInstance(class LoxClass) *LoxInstance {
-	return &LoxInstance{class, make(map[string]interface{})}
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{class: class, fields: make(map[string]interface{})}
 }
 
 func (l *LoxInstance) get(name Token) (interface{}, error) {
-	field, prs := l.fields[name.lexeme]
+	field, prs := l.field(name.lexeme)
 	if prs {
 		return field, nil
 	}
@@ -20,13 +39,107 @@ func (l *LoxInstance) get(name Token) (interface{}, error) {
 		return method.bind(l), nil
 	}
 
+	if l.class.record {
+		if method, prs := recordMethod(l, name); prs {
+			return method, nil
+		}
+	}
+
 	return nil, &RuntimeError{name, "Undefiend property '" + name.lexeme + "'."}
 }
 
-func (l *LoxInstance) set(name Token, value interface{}) {
+func (l *LoxInstance) set(name Token, value interface{}) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.frozen {
+		return &RuntimeError{name, "Can't set property '" + name.lexeme + "' on a frozen instance."}
+	}
 	l.fields[name.lexeme] = value
+	return nil
+}
+
+// field returns the value of a field stored on the instance
+func (l *LoxInstance) field(name string) (interface{}, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	value, prs := l.fields[name]
+	return value, prs
+}
+
+// fieldValues returns a copy of the fields stored on the instance
+func (l *LoxInstance) fieldValues() map[string]interface{} {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	fields := make(map[string]interface{}, len(l.fields))
+	for name, value := range l.fields {
+		fields[name] = value
+	}
+	return fields
+}
+
+func (l *LoxInstance) freeze() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.frozen = true
+}
+
+func (l *LoxInstance) isFrozen() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.frozen
+}
+
+// privateField returns the value of a private field set by a class
+func (l *LoxInstance) privateField(name privateName) (interface{}, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	value, prs := l.private[name]
+	return value, prs
+}
+
+// setPrivateField stores a private field unless the instance is frozen
+func (l *LoxInstance) setPrivateField(name privateName, token Token, value interface{}) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.frozen {
+		return &RuntimeError{token, "Can't set property '" + token.lexeme + "' on a frozen instance."}
+	}
+	if l.private == nil {
+		l.private = make(map[privateName]interface{})
+	}
+	l.private[name] = value
+	return nil
 }
 
-func (l LoxInstance) String() string {
+// decoratedMethod returns a method whose decorators have already been
+// applied for this instance
+func (l *LoxInstance) decoratedMethod(name string) (interface{}, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	value, prs := l.decorated[name]
+	return value, prs
+}
+
+// keepDecorated stores a method once its decorators have been applied. If
+// another task got there first its method is kept instead, so every caller
+// sees the same one
+func (l *LoxInstance) keepDecorated(name string, value interface{}) interface{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if existing, prs := l.decorated[name]; prs {
+		return existing
+	}
+	if l.decorated == nil {
+		l.decorated = make(map[string]interface{})
+	}
+	l.decorated[name] = value
+	return value
+}
+
+func (l *LoxInstance) String() string {
+	if l.class.members != nil {
+		name, _ := l.field("name")
+		return l.class.name + "." + name.(string)
+	}
 	return l.class.name + " instance"
 }
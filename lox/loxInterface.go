@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// LoxInterface is a named set of method signatures. A class that declares it
+// implements the interface is checked for each of the methods when the class
+// is defined
+type LoxInterface struct {
+	name    string
+	methods []Function
+}
+
+func (l *LoxInterface) String() string {
+	return l.name
+}
+
+// checkConformance returns an error if the class is missing one of the
+// interface's methods or can't be called with the same number of arguments
+func (l *LoxInterface) checkConformance(class *LoxClass, token Token) error {
+	for _, signature := range l.methods {
+		name := signature.name.lexeme
+		method, prs := class.findMethod(name)
+		if !prs {
+			return &RuntimeError{token, "Class '" + class.name + "' does not implement '" +
+				name + "' from interface '" + l.name + "'."}
+		}
+		if signature.getter != method.declaration.getter {
+			kind := "a method"
+			if signature.getter {
+				kind = "a getter"
+			}
+			return &RuntimeError{token, "'" + name + "' must be " + kind + " to implement interface '" + l.name + "'."}
+		}
+		min, max := parameterArity(signature.params)
+		methodMin, methodMax := method.arity()
+		if methodMin > min || (methodMax != -1 && (max == -1 || methodMax < max)) {
+			return &RuntimeError{token, fmt.Sprintf("Method '%s' must accept %s arguments to implement interface '%s'.",
+				name, describeArity(min, max), l.name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import "sync"
+
+// LoxList is a list of values. Its length never changes once it has been
+// created, but spawned tasks can share it, so its elements are read and
+// written through the mutex
+type LoxList struct {
+	mutex    sync.RWMutex
+	elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+// element returns the element at position, which must be in the list
+func (l *LoxList) element(position int) interface{} {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.elements[position]
+}
+
+// snapshot returns a copy of the elements of the list
+func (l *LoxList) snapshot() []interface{} {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return append([]interface{}{}, l.elements...)
+}
+
+// get returns the element at the given index, reporting a runtime error
+// against the bracket token if the index is not a valid position in the list
+func (l *LoxList) get(bracket Token, index interface{}) (interface{}, error) {
+	position, err := l.position(bracket, index)
+	if err != nil {
+		return nil, err
+	}
+	return l.element(position), nil
+}
+
+func (l *LoxList) set(bracket Token, index interface{}, value interface{}) error {
+	position, err := l.position(bracket, index)
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.elements[position] = value
+	return nil
+}
+
+// position converts a lox index value into a position in the list
+func (l *LoxList) position(bracket Token, index interface{}) (int, error) {
+	num, ok := index.(float64)
+	if !ok || !isInteger(num) {
+		return 0, &RuntimeError{bracket, "List index must be an integer."}
+	}
+	if num < 0 || int(num) >= len(l.elements) {
+		return 0, &RuntimeError{bracket, "List index out of range."}
+	}
+	return int(num), nil
+}
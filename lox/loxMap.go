@@ -0,0 +1,97 @@
+package main
+
+import "sync"
+
+// LoxMap is a hash map that remembers the order its keys were first added
+// in, so that printing and iterating over it is predictable. Spawned tasks
+// can share maps, so every access goes through the mutex
+type LoxMap struct {
+	mutex  sync.RWMutex
+	keys   []interface{}
+	values map[interface{}]interface{}
+	// the record keys in the map by their hash, so that equal records can
+	// find the key they are stored under
+	records map[uint64][]*LoxInstance
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{values: make(map[interface{}]interface{}), records: make(map[uint64][]*LoxInstance)}
+}
+
+// key returns the key that a value is stored under. A record is stored
+// under the first equal record added to the map, which add registers. The
+// caller must hold the mutex
+func (l *LoxMap) key(key interface{}, add bool) interface{} {
+	record, ok := key.(*LoxInstance)
+	if !ok || !record.class.record {
+		return key
+	}
+	hash := hashValue(record)
+	for _, existing := range l.records[hash] {
+		if valuesEqual(existing, record) {
+			return existing
+		}
+	}
+	if add {
+		l.records[hash] = append(l.records[hash], record)
+	}
+	return key
+}
+
+// get returns the value stored under key or nil if there isn't one
+func (l *LoxMap) get(bracket Token, key interface{}) (interface{}, error) {
+	err := checkMapKey(bracket, key)
+	if err != nil {
+		return nil, err
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.values[l.key(key, false)], nil
+}
+
+func (l *LoxMap) set(bracket Token, key interface{}, value interface{}) error {
+	err := checkMapKey(bracket, key)
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	key = l.key(key, true)
+	if _, prs := l.values[key]; !prs {
+		l.keys = append(l.keys, key)
+	}
+	l.values[key] = value
+	return nil
+}
+
+// lookup returns the value stored under key and whether there is one
+func (l *LoxMap) lookup(key interface{}) (interface{}, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	value, prs := l.values[l.key(key, false)]
+	return value, prs
+}
+
+// entries returns copies of the keys of the map and their values, in the
+// order the keys were added
+func (l *LoxMap) entries() ([]interface{}, []interface{}) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	keys := append([]interface{}{}, l.keys...)
+	values := make([]interface{}, len(keys))
+	for index, key := range keys {
+		values[index] = l.values[key]
+	}
+	return keys, values
+}
+
+// checkMapKey reports an error for values that can't be used as keys.
+// Primitive values and records are compared by value and other objects by
+// identity
+func checkMapKey(bracket Token, key interface{}) error {
+	switch key.(type) {
+	case nil, bool, float64, string, *LoxInstance, *LoxClass, *LoxList, *LoxMap:
+		return nil
+	}
+	return &RuntimeError{bracket, "Map keys must be numbers, strings, booleans, nil or objects."}
+}
@@ -0,0 +1,8 @@
+package main
+
+// LoxObject is any runtime value that has properties which can be read and
+// written with the "." operator, such as instances and classes
+type LoxObject interface {
+	get(name Token) (interface{}, error)
+	set(name Token, value interface{}) error
+}
@@ -0,0 +1,156 @@
+package main
+
+// LoxPromise holds a value that will be available later, or the reason it
+// never will be if it is rejected. Callbacks added with then and catch are
+// run by the event loop once the promise has been settled, never straight
+// away, so code after a call to then always runs first
+type LoxPromise struct {
+	loop      *EventLoop
+	settled   bool
+	rejected  bool
+	value     interface{}
+	reactions []reaction
+	// set once something has subscribed to the outcome of the promise
+	handled bool
+	// the runtime error that rejected the promise, reported by the event
+	// loop if nothing handles the rejection
+	failure *RuntimeError
+}
+
+// reaction is a pair of callbacks waiting for a promise to be settled. The
+// first is called if it is resolved and the second if it is rejected
+type reaction struct {
+	resolved func(interface{}) error
+	rejected func(interface{}) error
+}
+
+// rejection is what an async function is resumed with when the promise it
+// is awaiting has been rejected
+type rejection struct {
+	reason interface{}
+}
+
+func NewLoxPromise(loop *EventLoop) *LoxPromise {
+	return &LoxPromise{loop: loop}
+}
+
+// resolve fulfils the promise with value. Resolving with another promise
+// waits for that promise instead, and settling twice does nothing
+func (l *LoxPromise) resolve(value interface{}) {
+	if l.settled {
+		return
+	}
+	if promise, ok := value.(*LoxPromise); ok {
+		promise.subscribe(func(value interface{}) error {
+			l.resolve(value)
+			return nil
+		}, func(reason interface{}) error {
+			l.rejectWith(reason, promise.failure)
+			return nil
+		})
+		return
+	}
+	l.settle(false, value)
+}
+
+// reject settles the promise with the reason it failed
+func (l *LoxPromise) reject(reason interface{}) {
+	l.rejectWith(reason, nil)
+}
+
+// fail rejects the promise with the message of a runtime error. If nothing
+// handles the rejection the error is reported once the event loop finishes
+func (l *LoxPromise) fail(err *RuntimeError) {
+	l.rejectWith(err.message, err)
+}
+
+// rejectWith rejects the promise, keeping the runtime error that caused the
+// rejection if there was one
+func (l *LoxPromise) rejectWith(reason interface{}, err *RuntimeError) {
+	if l.settled {
+		return
+	}
+	if err != nil {
+		l.failure = err
+		l.loop.failures = append(l.loop.failures, l)
+	}
+	l.settle(true, reason)
+}
+
+func (l *LoxPromise) settle(rejected bool, value interface{}) {
+	l.settled = true
+	l.rejected = rejected
+	l.value = value
+	for _, reaction := range l.reactions {
+		l.react(reaction)
+	}
+	l.reactions = nil
+}
+
+func (l *LoxPromise) react(reaction reaction) {
+	if l.rejected {
+		l.loop.enqueue(reaction.rejected, l.value)
+	} else {
+		l.loop.enqueue(reaction.resolved, l.value)
+	}
+}
+
+// subscribe arranges for resolved to be called with the promise's value or
+// rejected to be called with its reason once it has been settled
+func (l *LoxPromise) subscribe(resolved func(interface{}) error, rejected func(interface{}) error) {
+	l.handled = true
+	reaction := reaction{resolved, rejected}
+	if l.settled {
+		l.react(reaction)
+		return
+	}
+	l.reactions = append(l.reactions, reaction)
+}
+
+// get exposes then(fn) and catch(fn), which both return a new promise. then
+// calls fn with the value of a resolved promise and catch calls fn with the
+// reason a promise was rejected. Whichever one isn't called passes the
+// outcome on to the new promise unchanged
+func (l *LoxPromise) get(name Token) (interface{}, error) {
+	switch name.lexeme {
+	case "then", "catch":
+		return &NativeFunction{name.lexeme, 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			function, ok := arguments[0].(Callable)
+			if !ok || !acceptsArguments(function, 1) {
+				return nil, nativeError(name.lexeme + "() expects a function that takes one argument.")
+			}
+			result := NewLoxPromise(l.loop)
+			handle := func(value interface{}) error {
+				value, err := function.call(interpreter, []interface{}{value})
+				if err != nil {
+					return err
+				}
+				result.resolve(value)
+				return nil
+			}
+			resolve := func(value interface{}) error {
+				result.resolve(value)
+				return nil
+			}
+			reject := func(reason interface{}) error {
+				result.rejectWith(reason, l.failure)
+				return nil
+			}
+			if name.lexeme == "then" {
+				l.subscribe(handle, reject)
+			} else {
+				l.subscribe(resolve, handle)
+			}
+			return result, nil
+		}}, nil
+	}
+	return nil, &RuntimeError{name, "Undefined property '" + name.lexeme + "'."}
+}
+
+func (l *LoxPromise) set(name Token, value interface{}) error {
+	return &RuntimeError{name, "Can't add properties to a promise."}
+}
+
+func (l *LoxPromise) String() string {
+	return "<promise>"
+}
@@ -0,0 +1,143 @@
+package main
+
+import "math"
+
+// LoxRange is the value of a range expression such as "1..10" or "0..<n".
+// Its elements are worked out as they are needed rather than being stored,
+// so iterating over a range never allocates a list
+type LoxRange struct {
+	start     float64
+	end       float64
+	step      float64
+	inclusive bool
+	reversed  bool
+}
+
+func NewLoxRange(start float64, end float64, inclusive bool) *LoxRange {
+	return &LoxRange{start: start, end: end, step: 1, inclusive: inclusive}
+}
+
+// length returns the number of elements in the range. Ranges with an end
+// before their start are empty, and ranges with more elements than an int
+// can count are cut off at math.MaxInt, which is still more than could
+// ever be iterated over
+func (l *LoxRange) length() int {
+	span := (l.end - l.start) / l.step
+	if !(span >= 0) {
+		return 0
+	}
+	if l.inclusive {
+		span = math.Floor(span) + 1
+	} else {
+		span = math.Ceil(span)
+	}
+	if span >= math.MaxInt {
+		return math.MaxInt
+	}
+	return int(span)
+}
+
+// at returns the element at position index, counting from the end if the
+// range has been reversed
+func (l *LoxRange) at(index int) float64 {
+	if l.reversed {
+		index = l.length() - 1 - index
+	}
+	return l.start + float64(index)*l.step
+}
+
+// contains returns true if n is one of the elements of the range
+func (l *LoxRange) contains(n float64) bool {
+	offset := (n - l.start) / l.step
+	if offset < 0 || offset != math.Trunc(offset) {
+		return false
+	}
+	// the span is compared against directly rather than the length, which
+	// is cut off for very long ranges
+	span := (l.end - l.start) / l.step
+	if l.inclusive {
+		return offset <= span
+	}
+	return offset < span
+}
+
+// get exposes the range's combinators to lox code. step and reverse return
+// new ranges while map and filter return lazy sequences
+func (l *LoxRange) get(name Token) (interface{}, error) {
+	switch name.lexeme {
+	case "step":
+		return &NativeFunction{"step", 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			step, ok := arguments[0].(float64)
+			if !ok || step <= 0 || math.IsInf(step, 0) {
+				return nil, nativeError("Range step must be a positive number.")
+			}
+			result := *l
+			result.step = step
+			return &result, nil
+		}}, nil
+	case "reverse":
+		return &NativeFunction{"reverse", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			result := *l
+			result.reversed = !l.reversed
+			return &result, nil
+		}}, nil
+	}
+	return sequenceMethod(l, name)
+}
+
+func (l *LoxRange) set(name Token, value interface{}) error {
+	return &RuntimeError{name, "Can't add properties to a range."}
+}
+
+func (l *LoxRange) String() string {
+	operator := ".."
+	if !l.inclusive {
+		operator = "..<"
+	}
+	text := formatNumber(l.start) + operator + formatNumber(l.end)
+	if l.step != 1 {
+		text = "(" + text + ").step(" + formatNumber(l.step) + ")"
+	}
+	if l.reversed {
+		text += ".reverse()"
+	}
+	return text
+}
+
+// LoxSequence lazily applies a function to the values of another iterable,
+// either transforming each value or using the result to decide whether the
+// value is kept
+type LoxSequence struct {
+	source   interface{}
+	function Callable
+	filter   bool
+}
+
+// sequenceMethod looks up the map and filter combinators that ranges and
+// sequences share
+func sequenceMethod(source interface{}, name Token) (interface{}, error) {
+	switch name.lexeme {
+	case "map", "filter":
+		filter := name.lexeme == "filter"
+		return &NativeFunction{name.lexeme, 1, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			function, ok := arguments[0].(Callable)
+			if !ok || !acceptsArguments(function, 1) {
+				return nil, nativeError("Expected a function that takes one argument.")
+			}
+			return &LoxSequence{source, function, filter}, nil
+		}}, nil
+	}
+	return nil, &RuntimeError{name, "Undefined property '" + name.lexeme + "'."}
+}
+
+func (l *LoxSequence) get(name Token) (interface{}, error) {
+	return sequenceMethod(l, name)
+}
+
+func (l *LoxSequence) set(name Token, value interface{}) error {
+	return &RuntimeError{name, "Can't add properties to a sequence."}
+}
+
+func (l *LoxSequence) String() string {
+	return "<sequence>"
+}
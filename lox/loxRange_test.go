@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestHugeRangesStillIterate(t *testing.T) {
+	output, errors := runScript(t, `
+fun firstThree(range) {
+  var count = 0;
+  for (i in range) {
+    print i;
+    count = count + 1;
+    if (count == 3) return;
+  }
+  print "done";
+}
+firstThree(0..1e300);
+firstThree((0..<1e20).step(1e19).reverse());
+firstThree(0..<(0/0));
+fun inRange(n) {
+  match (n) {
+    case 0..1e300 => return true;
+    case _ => return false;
+  }
+}
+print inRange(1e20);
+print inRange(2e300);
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "0", "1", "2", "9e+19", "8e+19", "7e+19", "done", "true", "false")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// components returns the fields of a record in the order they were
+// declared, which are the parameters of its generated initializer
+func (l *LoxClass) components() []Parameter {
+	return l.parameters()
+}
+
+// valuesEqual compares two values the way == does in lox. Records of the
+// same class are equal when all of their fields are equal, functions when
+// they share a declaration and closure, and everything else is compared with
+// golangs == operator when its type allows it
+func valuesEqual(a interface{}, b interface{}) bool {
+	if left, ok := a.(LoxFunction); ok {
+		right, ok := b.(LoxFunction)
+		return ok && sameFunction(left, right)
+	}
+	left, ok := a.(*LoxInstance)
+	if !ok || !left.class.record {
+		if a != nil && !reflect.TypeOf(a).Comparable() {
+			return false
+		}
+		return a == b
+	}
+	right, ok := b.(*LoxInstance)
+	if !ok || left.class != right.class {
+		return false
+	}
+	for _, component := range left.class.components() {
+		name := component.name.lexeme
+		leftValue, _ := left.field(name)
+		rightValue, _ := right.field(name)
+		if !valuesEqual(leftValue, rightValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameFunction returns true if two functions were created from the same
+// declaration in the same environment. Declarations are copied into each
+// function, so the one they came from is found through its body
+func sameFunction(a LoxFunction, b LoxFunction) bool {
+	return a.closure == b.closure &&
+		a.declaration.name == b.declaration.name &&
+		reflect.ValueOf(a.declaration.body).Pointer() == reflect.ValueOf(b.declaration.body).Pointer()
+}
+
+// hashValue returns a hash for a value that agrees with valuesEqual, so
+// equal records have the same hash. Other objects hash by identity and
+// values that aren't pointers hash by their type
+func hashValue(value interface{}) uint64 {
+	hash := fnv.New64a()
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 1
+		}
+		return 2
+	case float64:
+		// 0 and -0 are equal so they need the same hash
+		if value == 0 {
+			value = 0
+		}
+		return math.Float64bits(value)
+	case string:
+		hash.Write([]byte(value))
+		return hash.Sum64()
+	case LoxFunction:
+		declaration := uint64(reflect.ValueOf(value.declaration.body).Pointer())
+		return declaration*31 + uint64(reflect.ValueOf(value.closure).Pointer())
+	case *LoxInstance:
+		if value.class.record {
+			hash.Write([]byte(value.class.name))
+			result := hash.Sum64()
+			for _, component := range value.class.components() {
+				field, _ := value.field(component.name.lexeme)
+				result = result*31 + hashValue(field)
+			}
+			return result
+		}
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.Slice, reflect.UnsafePointer:
+		return uint64(reflect.ValueOf(value).Pointer())
+	}
+	hash.Write([]byte(reflect.TypeOf(value).String()))
+	return hash.Sum64()
+}
+
+// recordMethod returns one of the methods every record has. hashCode and
+// toString can be replaced by declaring them in the record's body
+func recordMethod(instance *LoxInstance, name Token) (interface{}, bool) {
+	switch name.lexeme {
+	case "with":
+		return &recordCopy{instance}, true
+	case "hashCode":
+		return &NativeFunction{"hashCode", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return float64(uint32(hashValue(instance))), nil
+		}}, true
+	case "toString":
+		return &NativeFunction{"toString", 0, 0, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			return interpreter.stringifyRecord(instance, make(map[interface{}]bool))
+		}}, true
+	}
+	return nil, false
+}
+
+// stringifyRecord shows a record the way it would be created with named
+// arguments, as in "Point(x: 1, y: 2)"
+func (i *Interpreter) stringifyRecord(instance *LoxInstance, visiting map[interface{}]bool) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(instance.class.name + "(")
+	for index, component := range instance.class.components() {
+		if index > 0 {
+			sb.WriteString(", ")
+		}
+		field, _ := instance.field(component.name.lexeme)
+		text, err := i.stringifyValue(field, visiting)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(component.name.lexeme + ": " + text)
+	}
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+// recordCopy is the with() method of a record. It makes a copy of the
+// record with the fields given as arguments replaced, as in "p.with(x: 3)"
+type recordCopy struct {
+	instance *LoxInstance
+}
+
+func (r *recordCopy) arity() (int, int) {
+	return 0, len(r.instance.class.components())
+}
+
+// parameters allows the fields to be replaced by name
+func (r *recordCopy) parameters() []Parameter {
+	return r.instance.class.components()
+}
+
+func (r *recordCopy) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	copy := NewLoxInstance(r.instance.class)
+	copy.fields = r.instance.fieldValues()
+	components := r.instance.class.components()
+	for index, argument := range arguments {
+		if argument != (missingArgument{}) {
+			copy.fields[components[index].name.lexeme] = argument
+		}
+	}
+	copy.frozen = true
+	return copy, nil
+}
+
+func (r *recordCopy) String() string {
+	return "<native fn>"
+}
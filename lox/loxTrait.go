@@ -0,0 +1,15 @@
+package main
+
+// LoxTrait is a named set of methods that can be mixed into any number of
+// classes. The methods are kept as declarations and only become functions
+// when a class uses the trait, so that "super" can refer to that class's
+// superclass
+type LoxTrait struct {
+	name    string
+	methods []Function
+	closure *Envionment
+}
+
+func (l *LoxTrait) String() string {
+	return l.name
+}
@@ -9,10 +9,13 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
 var hadError bool = false
 var hadRuntimeError bool = false
+var virtualClock bool = false
+var strictFields bool = false
 
 func main() {
 	flag.Usage = func() {
@@ -25,6 +28,8 @@ func main() {
 		flag.PrintDefaults()
 	}
 
+	flag.BoolVar(&virtualClock, "virtual-clock", false, "Fire timers without waiting and start clock() at 0")
+	flag.BoolVar(&strictFields, "strict", false, "Make assigning a field that the class doesn't declare an error")
 	flag.Parse()
 
 	args := flag.Args()
@@ -122,6 +127,8 @@ func run(line string) {
 	tokens := scanner.scanTokens()
 	parser := NewParser(tokens)
 	interpreter := NewInterpreter()
+	interpreter.loop.virtual = virtualClock
+	interpreter.strict = strictFields
 	statements := parser.parse()
 	if hadError {
 		return
@@ -141,7 +148,30 @@ func report(line int, where string, message string) {
 	hadError = true
 }
 
+// reportWarning reports a problem that doesn't stop the program from running
+func reportWarning(line int, where string, message string) {
+	fmt.Fprintf(os.Stderr, "[line %d] Warning%s: %s\n", line, where, message)
+}
+
+// reportLock stops spawned tasks reporting errors over the top of each other
+var reportLock sync.Mutex
+
 func reportRuntimeError(runtimeError RuntimeError) {
+	reportLock.Lock()
+	defer reportLock.Unlock()
 	fmt.Fprintf(os.Stderr, "%s\n[line %d]\n", runtimeError.message, runtimeError.token.line)
 	hadRuntimeError = true
 }
+
+// reportError reports an error that stopped the program. Anything other
+// than a runtime error is reported with just its message
+func reportError(err error) {
+	if runtimeError, ok := err.(*RuntimeError); ok {
+		reportRuntimeError(*runtimeError)
+		return
+	}
+	reportLock.Lock()
+	defer reportLock.Unlock()
+	fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+	hadRuntimeError = true
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// runScript runs source and returns what it printed to stdout and stderr
+func runScript(t *testing.T, source string) (string, string) {
+	t.Helper()
+	hadError = false
+	hadRuntimeError = false
+
+	stdout, stderr := os.Stdout, os.Stderr
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outWriter, errWriter
+	output := make(chan string)
+	errors := make(chan string)
+	go func() {
+		text, _ := io.ReadAll(outReader)
+		output <- string(text)
+	}()
+	go func() {
+		text, _ := io.ReadAll(errReader)
+		errors <- string(text)
+	}()
+
+	run(source)
+
+	outWriter.Close()
+	errWriter.Close()
+	os.Stdout, os.Stderr = stdout, stderr
+	return <-output, <-errors
+}
+
+// expectCompileError runs source and checks that it was rejected before
+// running with an error containing message
+func expectCompileError(t *testing.T, source string, message string) {
+	t.Helper()
+	output, errors := runScript(t, source)
+	if !hadError {
+		t.Fatalf("expected a compile error, got output %q", output)
+	}
+	if !strings.Contains(errors, message) {
+		t.Errorf("expected error %q, got %q", message, errors)
+	}
+}
+
+func expectLines(t *testing.T, output string, expected ...string) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected output %q, got %q", expected, lines)
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NativeFunction is a function implemented in Go that is made available to
+// lox programs as a global. Errors returned without a location are reported
+// at the call site by the interpreter. A maxArity of -1 makes it variadic
+type NativeFunction struct {
+	name     string
+	minArity int
+	maxArity int
+	function func(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+func (n *NativeFunction) arity() (int, int) {
+	return n.minArity, n.maxArity
+}
+
+func (n *NativeFunction) call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return n.function(interpreter, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// natives are defined in the global environment of every interpreter
+var natives = []*NativeFunction{
+	{"implements", 2, 2, implementsNative},
+	{"inspect", 1, 1, inspectNative},
+	{"type", 1, 1, typeNative},
+	{"classOf", 1, 1, classOfNative},
+	{"isInstance", 2, 2, isInstanceNative},
+	{"fields", 1, 1, fieldsNative},
+	{"methods", 1, 1, methodsNative},
+	{"hasField", 2, 2, hasFieldNative},
+	{"getField", 2, 2, getFieldNative},
+	{"setField", 3, 3, setFieldNative},
+	{"arity", 1, 1, arityNative},
+	{"annotations", 1, 2, annotationsNative},
+	{"freeze", 1, 1, freezeNative},
+	{"isFrozen", 1, 1, isFrozenNative},
+	{"channel", 0, 1, channelNative},
+	{"select", 1, 1, selectNative},
+	{"setTimeout", 2, 2, setTimeoutNative},
+	{"setInterval", 2, 2, setIntervalNative},
+	{"clearTimeout", 1, 1, clearTimerNative},
+	{"clearInterval", 1, 1, clearTimerNative},
+	{"promise", 1, 1, promiseNative},
+	{"delay", 1, 1, delayNative},
+	{"all", 1, 1, allNative},
+	{"format", 1, -1, formatNative},
+}
+
+// nativeError creates a runtime error without a location, which the
+// interpreter fills in with the token of the call that raised it
+func nativeError(message string) error {
+	return &RuntimeError{Token{}, message}
+}
+
+// implements(value, interface) returns true if the value is a class, or an
+// instance of a class, that declares it implements the interface either
+// directly or through one of its superclasses
+func implementsNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	iface, ok := arguments[1].(*LoxInterface)
+	if !ok {
+		return nil, nativeError("Second argument to implements() must be an interface.")
+	}
+
+	var class *LoxClass
+	switch value := arguments[0].(type) {
+	case *LoxInstance:
+		class = value.class
+	case *LoxClass:
+		class = value
+	default:
+		return false, nil
+	}
+
+	for ; class != nil; class = class.superclass {
+		for _, implemented := range class.interfaces {
+			if implemented == iface {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// inspect(value) returns a description of the value for debugging. Unlike
+// print it ignores toString() and shows the class name and fields of each
+// instance, descending into fields and list elements. Objects that contain
+// themselves are shown as <cycle> rather than being followed again
+func inspectNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	var sb strings.Builder
+	err := interpreter.inspect(&sb, arguments[0], make(map[interface{}]bool))
+	if err != nil {
+		return nil, err
+	}
+	return sb.String(), nil
+}
+
+// inspect writes the description of value to sb. visiting holds the objects
+// currently being described further up the tree, which are the only ones
+// that can form a cycle with value
+func (i *Interpreter) inspect(sb *strings.Builder, value interface{}, visiting map[interface{}]bool) error {
+	switch value := value.(type) {
+	case string:
+		sb.WriteString(fmt.Sprintf("%q", value))
+	case *LoxList:
+		if visiting[value] {
+			sb.WriteString("<cycle>")
+			return nil
+		}
+		visiting[value] = true
+		defer delete(visiting, value)
+
+		sb.WriteString("[")
+		for index, element := range value.snapshot() {
+			if index > 0 {
+				sb.WriteString(", ")
+			}
+			if err := i.inspect(sb, element, visiting); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("]")
+	case *LoxMap:
+		if visiting[value] {
+			sb.WriteString("<cycle>")
+			return nil
+		}
+		visiting[value] = true
+		defer delete(visiting, value)
+
+		sb.WriteString("{")
+		keys, values := value.entries()
+		for index, key := range keys {
+			if index > 0 {
+				sb.WriteString(", ")
+			}
+			if err := i.inspect(sb, key, visiting); err != nil {
+				return err
+			}
+			sb.WriteString(": ")
+			if err := i.inspect(sb, values[index], visiting); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("}")
+	case *LoxInstance:
+		if visiting[value] {
+			sb.WriteString("<cycle>")
+			return nil
+		}
+		visiting[value] = true
+		defer delete(visiting, value)
+
+		sb.WriteString(value.class.name + " {")
+		fields := value.fieldValues()
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for index, name := range names {
+			if index > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(" " + name + ": ")
+			if err := i.inspect(sb, fields[name], visiting); err != nil {
+				return err
+			}
+		}
+		if len(names) > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("}")
+	case *LoxClass:
+		sb.WriteString("<class " + value.name + ">")
+	default:
+		text, err := i.stringify(value)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(text)
+	}
+	return nil
+}
+
+// freeze(instance) stops any of the instance's fields being set or added
+// and returns the instance
+func freezeNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance, ok := arguments[0].(*LoxInstance)
+	if !ok {
+		return nil, nativeError("Only instances can be frozen.")
+	}
+	instance.freeze()
+	return instance, nil
+}
+
+// isFrozen(value) returns true if the value is an instance that was frozen
+func isFrozenNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance, ok := arguments[0].(*LoxInstance)
+	return ok && instance.isFrozen(), nil
+}
+
+// type(value) returns the name of the kind of value as a string
+func typeNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch arguments[0].(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return "boolean", nil
+	case float64:
+		return "number", nil
+	case string:
+		return "string", nil
+	case *LoxList:
+		return "list", nil
+	case *LoxMap:
+		return "map", nil
+	case *LoxGenerator:
+		return "generator", nil
+	case *LoxRange:
+		return "range", nil
+	case *LoxSequence:
+		return "sequence", nil
+	case *LoxChannel:
+		return "channel", nil
+	case *LoxPromise:
+		return "promise", nil
+	case *LoxInstance:
+		return "instance", nil
+	case *LoxClass:
+		return "class", nil
+	case *LoxTrait:
+		return "trait", nil
+	case *LoxInterface:
+		return "interface", nil
+	case Callable:
+		return "function", nil
+	}
+	return "unknown", nil
+}
+
+// classOf(value) returns the class of an instance or the metaclass of a
+// class. Other values don't have a class so nil is returned
+func classOfNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if class := classOf(arguments[0]); class != nil {
+		return class, nil
+	}
+	return nil, nil
+}
+
+// isInstance(value, class) returns true if the class of the value is the
+// given class or inherits from it
+func isInstanceNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	target, ok := arguments[1].(*LoxClass)
+	if !ok {
+		return nil, nativeError("Second argument to isInstance() must be a class.")
+	}
+	return isInstanceOf(arguments[0], target), nil
+}
+
+// annotations(value) returns the annotations of a function or class, which
+// are its decorators that aren't functions. annotations(class, name) reads
+// them from one of the class's methods instead
+func annotationsNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	var annotations []interface{}
+	switch value := arguments[0].(type) {
+	case *LoxFunction:
+		annotations = value.annotations
+	case LoxFunction:
+		annotations = value.annotations
+	case *LoxClass:
+		annotations = value.annotations
+		if len(arguments) == 2 {
+			name, ok := arguments[1].(string)
+			if !ok {
+				return nil, nativeError("Method name must be a string.")
+			}
+			method, prs := value.findMethod(name)
+			if !prs {
+				return nil, nativeError("Undefined method '" + name + "'.")
+			}
+			annotations = method.annotations
+		}
+	}
+	return NewLoxList(append([]interface{}{}, annotations...)), nil
+}
+
+// isInstanceOf returns true if the class of value is target or a subclass
+// of it
+func isInstanceOf(value interface{}, target *LoxClass) bool {
+	for class := classOf(value); class != nil; class = class.superclass {
+		if class == target {
+			return true
+		}
+	}
+	return false
+}
+
+// fields(object) returns a sorted list of the names of the fields stored on
+// an instance, or the static fields of a class
+func fieldsNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	fields, ok := fieldsOf(arguments[0])
+	if !ok {
+		return nil, nativeError("Only instances and classes have fields.")
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	return sortedNames(names), nil
+}
+
+// methods(class) returns a sorted list of the names of every method an
+// instance of the class responds to, including inherited ones. Passing an
+// instance lists the methods of its class
+func methodsNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	class, ok := arguments[0].(*LoxClass)
+	if instance, isInstance := arguments[0].(*LoxInstance); isInstance {
+		class, ok = instance.class, true
+	}
+	if !ok {
+		return nil, nativeError("Argument to methods() must be a class or instance.")
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for ; class != nil; class = class.superclass {
+		for name := range class.methods {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return sortedNames(names), nil
+}
+
+// hasField(object, name) returns true if the field is stored on the object
+func hasFieldNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	name, err := fieldName(arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := fieldsOf(arguments[0])
+	if !ok {
+		return false, nil
+	}
+	_, prs := fields[name]
+	return prs, nil
+}
+
+// fieldName checks the name given to one of the field natives. Private
+// members can only be reached from the methods of their class, so they
+// can't be named here
+func fieldName(value interface{}) (string, error) {
+	name, ok := value.(string)
+	if !ok {
+		return "", nativeError("Field name must be a string.")
+	}
+	if strings.HasPrefix(name, "#") {
+		return "", nativeError("Can't access private member '" + name + "' by name.")
+	}
+	return name, nil
+}
+
+// getField(object, name) reads a property by name, the same as object.name
+func getFieldNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	name, err := fieldName(arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	return interpreter.getProperty(arguments[0], Token{IDENTIFIER, name, nil, 0})
+}
+
+// setField(object, name, value) stores a property by name, the same as
+// object.name = value, and returns the value
+func setFieldNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	name, err := fieldName(arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	err = interpreter.setProperty(arguments[0], Token{IDENTIFIER, name, nil, 0}, arguments[2])
+	if err != nil {
+		return nil, err
+	}
+	return arguments[2], nil
+}
+
+// arity(function) returns the number of arguments a function or class
+// needs to be called with, not counting ones that have defaults or are
+// collected by a rest parameter
+func arityNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	callable, ok := arguments[0].(Callable)
+	if !ok {
+		return nil, nativeError("Argument to arity() must be a function or class.")
+	}
+	min, _ := callable.arity()
+	return float64(min), nil
+}
+
+// format(template, values...) replaces each "{}" in the template with the
+// next value, printed the same way print would print it
+func formatNative(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	template, ok := arguments[0].(string)
+	if !ok {
+		return nil, nativeError("First argument to format() must be a string.")
+	}
+
+	values := arguments[1:]
+	parts := strings.Split(template, "{}")
+	if len(parts)-1 != len(values) {
+		return nil, nativeError(fmt.Sprintf("format() has %d placeholders but was given %d values.",
+			len(parts)-1, len(values)))
+	}
+
+	var sb strings.Builder
+	for index, part := range parts {
+		sb.WriteString(part)
+		if index < len(values) {
+			text, err := interpreter.stringify(values[index])
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(text)
+		}
+	}
+	return sb.String(), nil
+}
+
+// classOf returns the class of an instance or the metaclass of a class
+func classOf(value interface{}) *LoxClass {
+	switch value := value.(type) {
+	case *LoxInstance:
+		return value.class
+	case *LoxClass:
+		return value.metaclass
+	}
+	return nil
+}
+
+// fieldsOf returns a copy of the fields stored on an instance or class
+func fieldsOf(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case *LoxInstance:
+		return value.fieldValues(), true
+	case *LoxClass:
+		return value.fieldValues(), true
+	}
+	return nil, false
+}
+
+// sortedNames sorts the names and returns them as a lox list of strings
+func sortedNames(names []string) *LoxList {
+	sort.Strings(names)
+	elements := make([]interface{}, len(names))
+	for index, name := range names {
+		elements[index] = name
+	}
+	return NewLoxList(elements)
+}
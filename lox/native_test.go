@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const privateClasses = `
+class A {
+  init() { this.#x = 1; }
+  #hidden() { return "hidden"; }
+  reveal() { return this.#hidden(); }
+}
+class B < A {
+  peek() { return this.#hidden(); }
+}
+`
+
+// expectRuntimeError runs source and checks that it stopped with a runtime
+// error containing message
+func expectRuntimeError(t *testing.T, source string, message string) {
+	t.Helper()
+	output, errors := runScript(t, source)
+	if !hadRuntimeError {
+		t.Fatalf("expected a runtime error, got output %q", output)
+	}
+	if !strings.Contains(errors, message) {
+		t.Errorf("expected error %q, got %q", message, errors)
+	}
+}
+
+func TestPrivateMethodsWork(t *testing.T) {
+	output, errors := runScript(t, privateClasses+`
+print A().reveal();
+print B().reveal();
+print methods(A);
+`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "hidden", "hidden", "[init, reveal]")
+}
+
+func TestGetFieldCantCallPrivateMethod(t *testing.T) {
+	expectRuntimeError(t, privateClasses+`print getField(A(), "#hidden")();`,
+		"Can't access private member '#hidden' by name.")
+}
+
+func TestGetFieldCantCallInheritedPrivateMethod(t *testing.T) {
+	expectRuntimeError(t, privateClasses+`print getField(B(), "#hidden")();`,
+		"Can't access private member '#hidden' by name.")
+}
+
+func TestSubclassCantCallPrivateMethod(t *testing.T) {
+	expectRuntimeError(t, privateClasses+`print B().peek();`,
+		"Undefined private member '#hidden'.")
+}
+
+func TestSetFieldCantCreatePrivateField(t *testing.T) {
+	expectRuntimeError(t, privateClasses+`
+var a = A();
+setField(a, "#y", 1);
+`, "Can't access private member '#y' by name.")
+
+	output, errors := runScript(t, privateClasses+`print fields(A());`)
+	if errors != "" {
+		t.Fatalf("unexpected error: %s", errors)
+	}
+	expectLines(t, output, "[]")
+}
+
+func TestHasFieldCantSeePrivateField(t *testing.T) {
+	expectRuntimeError(t, privateClasses+`print hasField(A(), "#x");`,
+		"Can't access private member '#x' by name.")
+}
@@ -0,0 +1,37 @@
+package main
+
+// Parameter is one of the parameters in a function declaration. A parameter
+// with a defaultValue can be left out of a call, and a rest parameter
+// collects any arguments left over into a list
+type Parameter struct {
+	name         Token
+	defaultValue Expr
+	rest         bool
+}
+
+// NamedArgument is an argument passed by naming the parameter it is for,
+// as in "f(a: 1)"
+type NamedArgument struct {
+	name  Token
+	value Expr
+}
+
+// missingArgument fills the places of parameters that were skipped over by
+// named arguments, so that their default values are used instead
+type missingArgument struct{}
+
+// parameterArity returns the smallest and largest number of arguments that
+// can be passed for the parameters. The largest is -1 if there is a rest
+// parameter
+func parameterArity(parameters []Parameter) (int, int) {
+	required := 0
+	for _, parameter := range parameters {
+		if parameter.rest {
+			return required, -1
+		}
+		if parameter.defaultValue == nil {
+			required++
+		}
+	}
+	return required, len(parameters)
+}
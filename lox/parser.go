@@ -2,11 +2,16 @@ package main
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Parser struct {
 	tokens  []Token
 	current int
+	// set when a yield is parsed inside the function currently being parsed
+	yielded bool
+	// doc comments keyed by the index of the token that follows them
+	docs map[int]string
 }
 
 // parse is the entry point for the parser
@@ -20,16 +25,40 @@ func (p *Parser) parse() []Stmt {
 }
 
 // declaration represents the declaration rule of the grammar
-// declaration -> varDecl | statement | funDecl | classDecl
+// declaration -> varDecl | constDecl | destructuringDecl | statement | funDecl | asyncFunDecl | classDecl
+// | traitDecl | interfaceDecl
 func (p *Parser) declaration() Stmt {
 	var stmt Stmt
 	var err error
-	if p.match(CLASS) {
-		stmt, err = p.classDeclaration()
+	doc := p.docs[p.current]
+	if p.check(AT) {
+		stmt, err = p.decoratedDeclaration()
+	} else if p.match(CLASS) {
+		stmt, err = p.classDeclaration(false)
+	} else if p.match(RECORD) {
+		stmt, err = p.classDeclaration(true)
+	} else if p.match(ENUM) {
+		stmt, err = p.enumDeclaration()
+	} else if p.match(TRAIT) {
+		stmt, err = p.traitDeclaration()
+	} else if p.match(INTERFACE) {
+		stmt, err = p.interfaceDeclaration()
 	} else if p.match(FUN) {
 		stmt, err = p.function("function")
+	} else if p.match(ASYNC) {
+		stmt, err = p.asyncFunction()
 	} else if p.match(VAR) {
-		stmt, err = p.varDeclaration()
+		if p.check(LEFT_BRACKET) || p.check(LEFT_BRACE) {
+			stmt, err = p.destructuringDeclaration(false)
+		} else {
+			stmt, err = p.varDeclaration()
+		}
+	} else if p.match(CONST, LET) {
+		if p.check(LEFT_BRACKET) || p.check(LEFT_BRACE) {
+			stmt, err = p.destructuringDeclaration(true)
+		} else {
+			stmt, err = p.constDeclaration()
+		}
 	} else {
 		stmt, err = p.statement()
 	}
@@ -38,18 +67,83 @@ func (p *Parser) declaration() Stmt {
 		p.synchonize()
 		return nil
 	}
+	attachDoc(stmt, doc)
 	return stmt
 }
 
-// classDecl -> "class" IDENTIFIER ( "<" IDENTIFIER )? "{" function* "}" ;
-func (p *Parser) classDeclaration() (Stmt, error) {
+// attachDoc sets the doc comment written above a declaration
+func attachDoc(stmt Stmt, doc string) {
+	switch stmt := stmt.(type) {
+	case *Class:
+		stmt.doc = doc
+	case *DestructureVar:
+		stmt.doc = doc
+	case *Enum:
+		stmt.doc = doc
+	case *Function:
+		stmt.doc = doc
+	case *Interface:
+		stmt.doc = doc
+	case *Trait:
+		stmt.doc = doc
+	case *Var:
+		stmt.doc = doc
+	}
+}
+
+// docComment returns the doc comment written above a declaration
+func docComment(stmt Stmt) string {
+	switch stmt := stmt.(type) {
+	case *Class:
+		return stmt.doc
+	case *DestructureVar:
+		return stmt.doc
+	case *Enum:
+		return stmt.doc
+	case *Function:
+		return stmt.doc
+	case *Interface:
+		return stmt.doc
+	case *Trait:
+		return stmt.doc
+	case *Var:
+		return stmt.doc
+	}
+	return ""
+}
+
+// classDecl -> "class" IDENTIFIER ( "<" IDENTIFIER )?
+// ( "with" IDENTIFIER ( "," IDENTIFIER )* )?
+// ( "implements" IDENTIFIER ( "," IDENTIFIER )* )? "{" classMember* "}" ;
+// classMember -> "class"? function | "class"? varDecl ;
+// recordDecl -> "record" IDENTIFIER "(" parameters? ")"
+// ( "with" ... )? ( "implements" ... )? ( "{" classMember* "}" | ";" ) ;
+func (p *Parser) classDeclaration(record bool) (Stmt, error) {
 	name, err := p.consume(IDENTIFIER, "Expect class name.")
 	if err != nil {
 		return nil, err
 	}
 
+	// a record's fields are declared like the parameters of its initializer
+	var components []Parameter
+	if record {
+		_, err = p.consume(LEFT_PAREN, "Expect '(' after record name.")
+		if err != nil {
+			return nil, err
+		}
+		components, err = p.parameters()
+		if err != nil {
+			return nil, err
+		}
+		for _, component := range components {
+			if component.rest {
+				p.error(component.name, "A record can't have a rest component.")
+			}
+		}
+	}
+
 	var superclass *Variable = nil
-	if p.match(LESS) {
+	if !record && p.match(LESS) {
 		_, err := p.consume(IDENTIFIER, "Expect superclass name.")
 		if err != nil {
 			return nil, err
@@ -57,55 +151,397 @@ func (p *Parser) classDeclaration() (Stmt, error) {
 		superclass = &Variable{p.previous()}
 	}
 
+	var traits []*Variable
+	if p.match(WITH) {
+		traits, err = p.identifierList("Expect trait name.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// "implements" is only a keyword in this position so that it can still
+	// be used as the name of the implements() native
+	var interfaces []*Variable
+	if p.check(IDENTIFIER) && p.peek().lexeme == "implements" {
+		p.advance()
+		interfaces, err = p.identifierList("Expect interface name.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var methods []Function
+	var classMethods []Function
+	var fields []Var
+	var classFields []Var
+	if record {
+		methods = append(methods, p.recordInitializer(name, components))
+		if p.match(SEMICOLON) {
+			return &Class{name, superclass, traits, interfaces, methods, classMethods, fields, classFields, true, nil, ""}, nil
+		}
+	}
+
 	_, err = p.consume(LEFT_BRACE, "Expect '{' before class body.")
 	if err != nil {
 		return nil, err
 	}
 
-	var methods []Function
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		doc := p.docs[p.current]
+		decorators, err := p.decorators()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.match(VAR) {
+			if decorators != nil {
+				p.error(p.previous(), "Only methods can be decorated.")
+			}
+			if record {
+				p.error(p.previous(), "A record can't declare fields.")
+			}
+			field, err := p.fieldDeclaration()
+			if err != nil {
+				return nil, err
+			}
+			for _, declared := range fields {
+				if declared.name.lexeme == field.name.lexeme {
+					p.error(field.name, "Field '"+field.name.lexeme+"' is already declared in this class.")
+				}
+			}
+			field.doc = doc
+			fields = append(fields, *field)
+			continue
+		}
+
+		if p.match(CLASS) {
+			if p.match(VAR) {
+				if decorators != nil {
+					p.error(p.previous(), "Only methods can be decorated.")
+				}
+				field, err := p.varDeclaration()
+				if err != nil {
+					return nil, err
+				}
+				field.(*Var).doc = doc
+				classFields = append(classFields, *field.(*Var))
+				continue
+			}
+
+			method, err := p.function("method")
+			if err != nil {
+				return nil, err
+			}
+			method.decorators = decorators
+			method.doc = doc
+			classMethods = append(classMethods, *method)
+			continue
+		}
+
 		method, err := p.function("method")
 		if err != nil {
 			return nil, err
 		}
+		if record && method.name.lexeme == "init" {
+			p.error(method.name, "A record can't declare an initializer.")
+		}
+		if method.getter && decorators != nil {
+			p.error(method.name, "Getters can't be decorated.")
+		}
+		method.decorators = decorators
+		method.doc = doc
 		methods = append(methods, *method)
 	}
 
 	p.consume(RIGHT_BRACE, "Expect '}' after class body.")
 
-	return &Class{name, superclass, methods}, nil
+	return &Class{name, superclass, traits, interfaces, methods, classMethods, fields, classFields, record, nil, ""}, nil
 }
 
-// function represents the function rule of the grammar
-// function -> IDENTIFIER "(" parameters? ")" block;
-func (p *Parser) function(kind string) (*Function, error) {
-	name, err := p.consume(IDENTIFIER, "Expect "+kind+"name.")
+// fieldDeclaration parses an instance field declared in a class body. The
+// initializer runs for every new instance before init is called
+// fieldDecl -> "var" ( IDENTIFIER | PRIVATE_NAME ) ( "=" expression )? ";" ;
+func (p *Parser) fieldDeclaration() (*Var, error) {
+	var name Token
+	var err error
+	if p.match(PRIVATE_NAME) {
+		name = p.previous()
+	} else {
+		name, err = p.consume(IDENTIFIER, "Expect field name.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var initializer Expr
+	if p.match(EQUAL) {
+		initializer, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = p.consume(SEMICOLON, "Expect ';' after field declaration.")
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.consume(LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	return &Var{name, initializer, false, ""}, nil
+}
+
+// recordInitializer generates the init method of a record, which stores
+// each of its parameters in the field with the same name
+func (p *Parser) recordInitializer(name Token, components []Parameter) Function {
+	var body []Stmt
+	for _, component := range components {
+		this := &This{Token{THIS, "this", nil, component.name.line}}
+		body = append(body, &Expression{&Set{this, component.name, &Variable{component.name}}})
+	}
+	return Function{Token{IDENTIFIER, "init", nil, name.line}, components, body, false, false, false, nil, ""}
+}
+
+// decoratedDecl -> decorator+ ( funDecl | asyncFunDecl | classDecl
+// | recordDecl ) ;
+func (p *Parser) decoratedDeclaration() (Stmt, error) {
+	decorators, err := p.decorators()
 	if err != nil {
 		return nil, err
 	}
-	var parameters []Token
-	if !p.check(RIGHT_PAREN) {
-		for {
-			if len(parameters) >= 255 {
-				p.error(p.peek(), "Can't have more than 255 parameters.")
+	switch {
+	case p.match(FUN):
+		function, err := p.function("function")
+		if err != nil {
+			return nil, err
+		}
+		function.decorators = decorators
+		return function, nil
+	case p.match(ASYNC):
+		stmt, err := p.asyncFunction()
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*Function).decorators = decorators
+		return stmt, nil
+	case p.match(CLASS, RECORD):
+		stmt, err := p.classDeclaration(p.previous().tokenType == RECORD)
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*Class).decorators = decorators
+		return stmt, nil
+	}
+	return nil, p.error(p.peek(), "Expect function or class declaration after decorator.")
+}
+
+// decorators parses the decorators in front of a declaration. Each is a
+// name, optionally followed by properties and a call, as in "@cache(10)"
+// decorator -> "@" IDENTIFIER ( "." IDENTIFIER )* ( "(" arguments? ")" )? ;
+func (p *Parser) decorators() ([]Decorator, error) {
+	var decorators []Decorator
+	for p.match(AT) {
+		at := p.previous()
+		name, err := p.consume(IDENTIFIER, "Expect decorator name after '@'.")
+		if err != nil {
+			return nil, err
+		}
+		var expression Expr = &Variable{name}
+		for p.match(DOT) {
+			property, err := p.propertyName("Expect property name after '.'.")
+			if err != nil {
+				return nil, err
 			}
+			expression = &Get{expression, property, false}
+		}
+		if p.match(LEFT_PAREN) {
+			expression, err = p.finishCall(expression)
+			if err != nil {
+				return nil, err
+			}
+		}
+		decorators = append(decorators, Decorator{at, expression})
+	}
+	return decorators, nil
+}
+
+// reservedEnumMembers are names that the properties of every enum and its
+// members would hide, so a member by that name couldn't be reached
+var reservedEnumMembers = map[string]bool{
+	"name":       true,
+	"superclass": true,
+	"values":     true,
+	"ordinal":    true,
+}
 
-			parameter, err := p.consume(IDENTIFIER, "Expect parameter name.")
+// enumDecl -> "enum" IDENTIFIER "{" IDENTIFIER ( "," IDENTIFIER )* ","?
+// ( ";" function* )? "}" ;
+func (p *Parser) enumDeclaration() (Stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect enum name.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LEFT_BRACE, "Expect '{' before enum body.")
+	if err != nil {
+		return nil, err
+	}
+
+	var members []Token
+	seen := make(map[string]bool)
+	for {
+		member, err := p.consume(IDENTIFIER, "Expect enum member name.")
+		if err != nil {
+			return nil, err
+		}
+		if seen[member.lexeme] {
+			return nil, p.error(member, "Duplicate enum member '"+member.lexeme+"'.")
+		}
+		if reservedEnumMembers[member.lexeme] {
+			return nil, p.error(member, "Can't use '"+member.lexeme+"' as an enum member name.")
+		}
+		seen[member.lexeme] = true
+		members = append(members, member)
+		if !p.match(COMMA) || p.check(RIGHT_BRACE) || p.check(SEMICOLON) {
+			break
+		}
+	}
+
+	var methods []Function
+	if p.match(SEMICOLON) {
+		for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+			doc := p.docs[p.current]
+			method, err := p.function("method")
 			if err != nil {
 				return nil, err
 			}
-			parameters = append(parameters, parameter)
+			method.doc = doc
+			methods = append(methods, *method)
+		}
+	}
 
-			if !p.match(COMMA) {
-				break
+	_, err = p.consume(RIGHT_BRACE, "Expect '}' after enum body.")
+	if err != nil {
+		return nil, err
+	}
+	return &Enum{name, members, methods, ""}, nil
+}
+
+// identifierList parses a comma separated list of names as variables
+func (p *Parser) identifierList(message string) ([]*Variable, error) {
+	var variables []*Variable
+	for {
+		name, err := p.consume(IDENTIFIER, message)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, &Variable{name})
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	return variables, nil
+}
+
+// interfaceDecl -> "interface" IDENTIFIER "{" signature* "}" ;
+// signature -> IDENTIFIER ( "(" parameters? ")" )? ";" ;
+func (p *Parser) interfaceDeclaration() (Stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect interface name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LEFT_BRACE, "Expect '{' before interface body.")
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []Function
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		doc := p.docs[p.current]
+		methodName, err := p.consume(IDENTIFIER, "Expect method name.")
+		if err != nil {
+			return nil, err
+		}
+
+		// a signature without a parameter list requires a getter
+		getter := true
+		var parameters []Parameter
+		if p.match(LEFT_PAREN) {
+			getter = false
+			parameters, err = p.parameters()
+			if err != nil {
+				return nil, err
 			}
 		}
+
+		_, err = p.consume(SEMICOLON, "Expect ';' after method signature.")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, Function{methodName, parameters, nil, getter, false, false, nil, doc})
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "Expect '}' after interface body.")
+	if err != nil {
+		return nil, err
+	}
+	return &Interface{name, methods, ""}, nil
+}
+
+// traitDecl -> "trait" IDENTIFIER "{" function* "}" ;
+func (p *Parser) traitDeclaration() (Stmt, error) {
+	name, err := p.consume(IDENTIFIER, "Expect trait name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LEFT_BRACE, "Expect '{' before trait body.")
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []Function
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		doc := p.docs[p.current]
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		method.doc = doc
+		methods = append(methods, *method)
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "Expect '}' after trait body.")
+	if err != nil {
+		return nil, err
+	}
+	return &Trait{name, methods, ""}, nil
+}
+
+// function represents the function rule of the grammar. Methods declared
+// without a parameter list are getters that run when the property is read
+// function -> IDENTIFIER ( "(" parameters? ")" )? block;
+func (p *Parser) function(kind string) (*Function, error) {
+	var name Token
+	var err error
+	if kind == "method" && p.match(PRIVATE_NAME) {
+		name = p.previous()
+	} else {
+		name, err = p.consume(IDENTIFIER, "Expect "+kind+"name.")
+		if err != nil {
+			return nil, err
+		}
 	}
-	_, err = p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+
+	if kind == "method" && p.match(LEFT_BRACE) {
+		body, generator, err := p.functionBody()
+		if err != nil {
+			return nil, err
+		}
+		return &Function{name, nil, body, true, generator, false, nil, ""}, nil
+	}
+
+	_, err = p.consume(LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := p.parameters()
 	if err != nil {
 		return nil, err
 	}
@@ -115,11 +551,87 @@ func (p *Parser) function(kind string) (*Function, error) {
 		return nil, err
 	}
 
+	body, generator, err := p.functionBody()
+	if err != nil {
+		return nil, err
+	}
+	return &Function{name, parameters, body, false, generator, false, nil, ""}, nil
+}
+
+// functionBody parses the block of a function after the opening "{" and
+// reports whether it contains a yield, which makes the function a generator.
+// Yields inside nested functions belong to those functions instead
+func (p *Parser) functionBody() ([]Stmt, bool, error) {
+	enclosing := p.yielded
+	p.yielded = false
 	body, err := p.block()
+	generator := p.yielded
+	p.yielded = enclosing
+	return body, generator, err
+}
+
+// asyncFunction parses a function declared with "async", which returns a
+// promise when it is called and can await other promises
+// asyncFunDecl -> "async" "fun" function ;
+func (p *Parser) asyncFunction() (Stmt, error) {
+	_, err := p.consume(FUN, "Expect 'fun' after 'async'.")
+	if err != nil {
+		return nil, err
+	}
+	function, err := p.function("function")
+	if err != nil {
+		return nil, err
+	}
+	function.async = true
+	return function, nil
+}
+
+// parameters parses a parameter list after the opening "(" up to and
+// including the closing ")". Parameters with defaults must come after the
+// ones without, and a rest parameter must come last
+// parameters -> parameter ( "," parameter )* ;
+// parameter -> IDENTIFIER ( "=" expression )? | "..." IDENTIFIER ;
+func (p *Parser) parameters() ([]Parameter, error) {
+	var parameters []Parameter
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(parameters) >= 255 {
+				p.error(p.peek(), "Can't have more than 255 parameters.")
+			}
+			if len(parameters) > 0 && parameters[len(parameters)-1].rest {
+				p.error(p.previous(), "Rest parameter must be the last parameter.")
+			}
+
+			rest := p.match(DOT_DOT_DOT)
+			name, err := p.consume(IDENTIFIER, "Expect parameter name.")
+			if err != nil {
+				return nil, err
+			}
+
+			var defaultValue Expr
+			if p.match(EQUAL) {
+				if rest {
+					p.error(p.previous(), "Rest parameter can't have a default value.")
+				}
+				defaultValue, err = p.assignment()
+				if err != nil {
+					return nil, err
+				}
+			} else if !rest && len(parameters) > 0 && parameters[len(parameters)-1].defaultValue != nil {
+				p.error(name, "Parameter without a default can't follow one with a default.")
+			}
+			parameters = append(parameters, Parameter{name, defaultValue, rest})
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	_, err := p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
 	if err != nil {
 		return nil, err
 	}
-	return &Function{name, parameters, body}, nil
+	return parameters, nil
 }
 
 // varDeclaration represents the var declaration rule of the grammar
@@ -141,12 +653,164 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Var{name, initializer}, nil
+	return &Var{name, initializer, false, ""}, nil
+}
+
+// constDeclaration parses a variable that can't be reassigned, so it must
+// be given a value when it is declared
+// constDecl -> ( "const" | "let" ) IDENTIFIER "=" expression ";" ;
+func (p *Parser) constDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	name, err := p.consume(IDENTIFIER, "Expect constant name.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(EQUAL, "Expect '=' after "+keyword.lexeme+" name.")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after variable declaration")
+	if err != nil {
+		return nil, err
+	}
+	return &Var{name, initializer, true, ""}, nil
+}
+
+// destructuringDeclaration parses a declaration that binds the parts of a
+// value to several variables at once
+// destructuringDecl -> ( "var" | "const" | "let" ) pattern "=" expression ";" ;
+func (p *Parser) destructuringDeclaration(constant bool) (Stmt, error) {
+	pattern, err := p.pattern()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(EQUAL, "Expect '=' after destructuring pattern.")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after variable declaration")
+	if err != nil {
+		return nil, err
+	}
+	return &DestructureVar{pattern, initializer, constant, ""}, nil
+}
+
+// pattern parses the list or object pattern of a destructuring declaration
+// pattern -> "[" ( element ( "," element )* )? "]"
+// | "{" ( property ( "," property )* )? "}" ;
+// element -> "..." IDENTIFIER | ( IDENTIFIER | pattern ) ( "=" expression )? ;
+// property -> IDENTIFIER ( ":" ( IDENTIFIER | pattern ) )? ( "=" expression )? ;
+func (p *Parser) pattern() (Pattern, error) {
+	if p.match(LEFT_BRACE) {
+		return p.objectPattern()
+	}
+
+	bracket, err := p.consume(LEFT_BRACKET, "Expect '[' or '{' to start a pattern.")
+	if err != nil {
+		return nil, err
+	}
+	pattern := &ListPattern{bracket: bracket}
+	if !p.check(RIGHT_BRACKET) {
+		for {
+			if p.match(DOT_DOT_DOT) {
+				rest, err := p.consume(IDENTIFIER, "Expect name after '...'.")
+				if err != nil {
+					return nil, err
+				}
+				pattern.rest = &rest
+				break
+			}
+
+			target, err := p.patternTarget()
+			if err != nil {
+				return nil, err
+			}
+			defaultValue, err := p.patternDefault()
+			if err != nil {
+				return nil, err
+			}
+			pattern.elements = append(pattern.elements, PatternElement{target, defaultValue})
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err = p.consume(RIGHT_BRACKET, "Expect ']' after list pattern.")
+	if err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// objectPattern parses the properties of an object pattern after the "{"
+func (p *Parser) objectPattern() (Pattern, error) {
+	pattern := &ObjectPattern{brace: p.previous()}
+	if !p.check(RIGHT_BRACE) {
+		for {
+			name, err := p.consume(IDENTIFIER, "Expect property name.")
+			if err != nil {
+				return nil, err
+			}
+
+			var target Pattern = &NamePattern{name}
+			if p.match(COLON) {
+				target, err = p.patternTarget()
+				if err != nil {
+					return nil, err
+				}
+			}
+			defaultValue, err := p.patternDefault()
+			if err != nil {
+				return nil, err
+			}
+			pattern.properties = append(pattern.properties, PropertyPattern{name, target, defaultValue})
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACE, "Expect '}' after object pattern.")
+	if err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// patternTarget parses the name or nested pattern that a part of the value
+// is bound to
+func (p *Parser) patternTarget() (Pattern, error) {
+	if p.check(LEFT_BRACKET) || p.check(LEFT_BRACE) {
+		return p.pattern()
+	}
+	name, err := p.consume(IDENTIFIER, "Expect variable name or pattern.")
+	if err != nil {
+		return nil, err
+	}
+	return &NamePattern{name}, nil
+}
+
+func (p *Parser) patternDefault() (Expr, error) {
+	if p.match(EQUAL) {
+		return p.assignment()
+	}
+	return nil, nil
 }
 
 // represents the statment rule of the grammar
 // statement -> exprStmt | forStmt | ifStmt | printStmt | returnStmt
-// | whileStmt | block ;
+// | whileStmt | matchStmt | block ;
 func (p *Parser) statement() (Stmt, error) {
 	if p.match(FOR) {
 		return p.forStatement()
@@ -163,6 +827,9 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(WHILE) {
 		return p.whileStatement()
 	}
+	if p.match(MATCH) {
+		return p.matchStatement()
+	}
 	if p.match(LEFT_BRACE) {
 		statements, err := p.block()
 		if err != nil {
@@ -196,7 +863,7 @@ func (p *Parser) returnStatement() (Stmt, error) {
 
 // represents the for statement rule of the grammar
 // forStmt -> "for" "(" ( varDecl | exprStmt | ";" ) expression? ";"
-// expression? ")" statement ;
+// expression? ")" statement | forInStmt ;
 func (p *Parser) forStatement() (Stmt, error) {
 	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'for'.")
 	if err != nil {
@@ -206,7 +873,12 @@ func (p *Parser) forStatement() (Stmt, error) {
 	var initializer Stmt
 	if p.match(SEMICOLON) {
 		initializer = nil
+	} else if p.check(IDENTIFIER) && p.checkNext(IN) {
+		return p.forInStatement()
 	} else if p.match(VAR) {
+		if p.check(IDENTIFIER) && p.checkNext(IN) {
+			return p.forInStatement()
+		}
 		initializer, err = p.varDeclaration()
 		if err != nil {
 			return nil, err
@@ -242,23 +914,213 @@ func (p *Parser) forStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	body, err := p.statement()
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	// desuguaring the for loop into a while loop structure
+	if increment != nil {
+		body = &Block{[]Stmt{body, &Expression{increment}}}
+	}
+	if condition == nil {
+		condition = &Literal{true}
+	}
+	body = &While{condition: condition, body: body}
+	if initializer != nil {
+		body = &Block{[]Stmt{initializer, body}}
+	}
+	return body, nil
+}
+
+// forInStatement parses the rest of a for-in loop once the loop variable
+// has been found
+// forInStmt -> "for" "(" "var"? IDENTIFIER "in" expression ")" statement ;
+func (p *Parser) forInStatement() (Stmt, error) {
+	name := p.advance()
+	keyword := p.advance()
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RIGHT_PAREN, "Expect ')' after for-in clauses.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return &ForIn{name, keyword, iterable, body}, nil
+}
+
+// represents the match statement rule of the grammar
+// matchStmt -> "match" "(" expression ")" "{" matchCase* "}" ;
+// matchCase -> "case" matchPattern ( "," matchPattern )*
+// ( "if" expression )? "=>" statement ;
+func (p *Parser) matchStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'match'.")
+	if err != nil {
+		return nil, err
+	}
+	subject, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RIGHT_PAREN, "Expect ')' after match value.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LEFT_BRACE, "Expect '{' before match cases.")
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []MatchCase
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		caseKeyword, err := p.consume(CASE, "Expect 'case'.")
+		if err != nil {
+			return nil, err
+		}
+
+		var patterns []Pattern
+		for {
+			pattern, err := p.matchPattern()
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, pattern)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+
+		var guard Expr
+		if p.match(IF) {
+			guard, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+		_, err = p.consume(ARROW, "Expect '=>' after case pattern.")
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, MatchCase{caseKeyword, patterns, guard, body})
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "Expect '}' after match cases.")
+	if err != nil {
+		return nil, err
+	}
+	return &Match{keyword, subject, cases}, nil
+}
+
+// matchPattern parses one pattern of a match case. A lowercase name binds
+// the value to a new variable while a capitalized one such as a class name
+// is compared against, as is any other expression
+// matchPattern -> "_" | IDENTIFIER | IDENTIFIER objectMatch | objectMatch
+// | "[" ( matchPattern ( "," matchPattern )* ( "," "..." IDENTIFIER )? )? "]"
+// | range ;
+// objectMatch -> "{" ( IDENTIFIER ( ":" matchPattern )? ( "," ... )* )? "}" ;
+func (p *Parser) matchPattern() (Pattern, error) {
+	if p.match(LEFT_BRACKET) {
+		pattern := &ListPattern{bracket: p.previous()}
+		if !p.check(RIGHT_BRACKET) {
+			for {
+				if p.match(DOT_DOT_DOT) {
+					rest, err := p.consume(IDENTIFIER, "Expect name after '...'.")
+					if err != nil {
+						return nil, err
+					}
+					pattern.rest = &rest
+					break
+				}
+				element, err := p.matchPattern()
+				if err != nil {
+					return nil, err
+				}
+				pattern.elements = append(pattern.elements, PatternElement{element, nil})
+				if !p.match(COMMA) {
+					break
+				}
+			}
+		}
+		_, err := p.consume(RIGHT_BRACKET, "Expect ']' after list pattern.")
+		if err != nil {
+			return nil, err
+		}
+		return pattern, nil
+	}
+
+	if p.match(LEFT_BRACE) {
+		return p.objectMatchPattern()
+	}
+
+	if p.check(IDENTIFIER) && p.checkNext(LEFT_BRACE) {
+		class := &Variable{p.advance()}
+		p.advance()
+		object, err := p.objectMatchPattern()
+		if err != nil {
+			return nil, err
+		}
+		return &ClassPattern{class, object}, nil
+	}
+
+	if p.check(IDENTIFIER) && !isCapitalized(p.peek().lexeme) && !p.checkNext(DOT) && !p.checkNext(LEFT_PAREN) && !p.checkNext(LEFT_BRACKET) {
+		name := p.advance()
+		if name.lexeme == "_" {
+			return &WildcardPattern{name}, nil
+		}
+		return &NamePattern{name}, nil
+	}
+
+	value, err := p.rangeExpression()
 	if err != nil {
 		return nil, err
 	}
+	return &ValuePattern{value}, nil
+}
 
-	// desuguaring the for loop into a while loop structure
-	if increment != nil {
-		body = &Block{[]Stmt{body, &Expression{increment}}}
-	}
-	if condition == nil {
-		condition = &Literal{true}
+// isCapitalized returns true if a name starts with an uppercase letter
+func isCapitalized(name string) bool {
+	return name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// objectMatchPattern parses the properties of an object pattern in a match
+// case after the "{". A property without a pattern binds it to its name
+func (p *Parser) objectMatchPattern() (*ObjectPattern, error) {
+	pattern := &ObjectPattern{brace: p.previous()}
+	if !p.check(RIGHT_BRACE) {
+		for {
+			name, err := p.consume(IDENTIFIER, "Expect property name.")
+			if err != nil {
+				return nil, err
+			}
+			var target Pattern = &NamePattern{name}
+			if p.match(COLON) {
+				target, err = p.matchPattern()
+				if err != nil {
+					return nil, err
+				}
+			}
+			pattern.properties = append(pattern.properties, PropertyPattern{name, target, nil})
+			if !p.match(COMMA) {
+				break
+			}
+		}
 	}
-	body = &While{condition: condition, body: body}
-	if initializer != nil {
-		body = &Block{[]Stmt{initializer, body}}
+	_, err := p.consume(RIGHT_BRACE, "Expect '}' after object pattern.")
+	if err != nil {
+		return nil, err
 	}
-	return body, nil
+	return pattern, nil
 }
 
 // represents the while statement rule of the grammar
@@ -344,9 +1206,11 @@ func (p *Parser) expressionStatement() (Stmt, error) {
 }
 
 // represents the assignment rule of the grammar
-// assignment -> ( call "." )? IDENTIFIER "=" assignment | logic_or ;
+// assignment -> ( call "." )? IDENTIFIER "=" assignment
+// | call "[" expression "]" "=" assignment | list "=" assignment
+// | target ( "+=" | "-=" | "*=" | "/=" | "%=" ) assignment | conditional ;
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
@@ -362,9 +1226,109 @@ func (p *Parser) assignment() (Expr, error) {
 			return &Assign{name, value}, nil
 		} else if get, ok := expr.(*Get); ok {
 			return &Set{get.object, get.name, value}, nil
+		} else if index, ok := expr.(*Index); ok {
+			return &IndexSet{index.object, index.bracket, index.index, value}, nil
+		} else if list, ok := expr.(*List); ok {
+			pattern, err := p.listTarget(list)
+			if err != nil {
+				return nil, err
+			}
+			return &Destructure{pattern, equals, value}, nil
 		}
 
 		p.error(equals, "Invalid assignment target.")
+	} else if p.match(PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL, PERCENT_EQUAL) {
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		if p.isAssignable(expr) {
+			return &Compound{expr, operator, value}, nil
+		}
+
+		p.error(operator, "Invalid assignment target.")
+	}
+	return expr, nil
+}
+
+// listTarget turns a list literal on the left hand side of an assignment
+// into a pattern. Elements written as "a = value" give a default value
+func (p *Parser) listTarget(list *List) (Pattern, error) {
+	pattern := &ListPattern{bracket: list.bracket}
+	for _, element := range list.elements {
+		var defaultValue Expr
+		if assign, ok := element.(*Assign); ok {
+			element = &Variable{assign.name}
+			defaultValue = assign.value
+		}
+
+		var target Pattern
+		if nested, ok := element.(*List); ok {
+			var err error
+			target, err = p.listTarget(nested)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.isAssignable(element) {
+			target = &TargetPattern{element}
+		} else {
+			return nil, p.error(list.bracket, "Invalid assignment target.")
+		}
+		pattern.elements = append(pattern.elements, PatternElement{target, defaultValue})
+	}
+	return pattern, nil
+}
+
+// isAssignable returns true if the expression can appear on the left hand
+// side of a compound assignment or as the operand of "++" or "--"
+func (p *Parser) isAssignable(expr Expr) bool {
+	switch expr.(type) {
+	case *Variable, *Get, *Index:
+		return true
+	}
+	return false
+}
+
+// conditional -> coalesce ( "?" expression ":" conditional )? ;
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(QUESTION) {
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(COLON, "Expect ':' after then branch of conditional expression.")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Conditional{expr, thenBranch, elseBranch}
+	}
+	return expr, nil
+}
+
+// coalesce -> logic_or ( "??" logic_or )* ;
+func (p *Parser) coalesce() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(QUESTION_QUESTION) {
+		operator := p.previous()
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Logical{expr, operator, right}
 	}
 	return expr, nil
 }
@@ -417,14 +1381,41 @@ func (p *Parser) printStatement() (Stmt, error) {
 	return &Print{value}, nil
 }
 
-// NewParser creates a new Parser with the provided tokens
+// NewParser creates a new Parser with the provided tokens. Doc comments are
+// taken out of the tokens and kept by the position of the token after them
 func NewParser(tokens []Token) *Parser {
-	return &Parser{tokens: tokens, current: 0}
+	parser := &Parser{current: 0, docs: make(map[int]string)}
+	var doc []string
+	for _, token := range tokens {
+		if token.tokenType == DOC_COMMENT {
+			doc = append(doc, token.literal.(string))
+			continue
+		}
+		if doc != nil {
+			parser.docs[len(parser.tokens)] = strings.Join(doc, "\n")
+			doc = nil
+		}
+		parser.tokens = append(parser.tokens, token)
+	}
+	return parser
 }
 
 // represents the expression rule of the grammar
-// expression -> assignment
+// expression -> "yield" expression? | assignment
 func (p *Parser) expression() (Expr, error) {
+	if p.match(YIELD) {
+		keyword := p.previous()
+		p.yielded = true
+		var value Expr
+		if !p.check(SEMICOLON) && !p.check(RIGHT_PAREN) && !p.check(RIGHT_BRACKET) && !p.check(COMMA) {
+			var err error
+			value, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &Yield{keyword, value}, nil
+	}
 	return p.assignment()
 }
 
@@ -448,14 +1439,110 @@ func (p *Parser) equality() (Expr, error) {
 }
 
 // represents the comparison rule of the grammar
-// comparison -> term ( ( ">" | ">=" | "<" | "<=" ) term )*
+// comparison -> range ( ( ">" | ">=" | "<" | "<=" ) range )*
 func (p *Parser) comparison() (Expr, error) {
-	expr, err := p.term()
+	expr, err := p.rangeExpression()
 	if err != nil {
 		return nil, err
 	}
 
 	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+		operator := p.previous()
+		right, err := p.rangeExpression()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{expr, operator, right}
+	}
+	return expr, nil
+}
+
+// represents the range rule of the grammar. Ranges don't chain so
+// "1..2..3" is an error
+// range -> bitwise_or ( ( ".." | "..<" ) bitwise_or )?
+func (p *Parser) rangeExpression() (Expr, error) {
+	expr, err := p.bitwiseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(DOT_DOT, DOT_DOT_LESS) {
+		operator := p.previous()
+		end, err := p.bitwiseOr()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Range{expr, operator, end}
+	}
+	return expr, nil
+}
+
+// represents the bitwise or rule of the grammar
+// bitwise_or -> bitwise_xor ( "|" bitwise_xor )*
+func (p *Parser) bitwiseOr() (Expr, error) {
+	expr, err := p.bitwiseXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(PIPE) {
+		operator := p.previous()
+		right, err := p.bitwiseXor()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{expr, operator, right}
+	}
+	return expr, nil
+}
+
+// represents the bitwise xor rule of the grammar
+// bitwise_xor -> bitwise_and ( "^" bitwise_and )*
+func (p *Parser) bitwiseXor() (Expr, error) {
+	expr, err := p.bitwiseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(CARET) {
+		operator := p.previous()
+		right, err := p.bitwiseAnd()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{expr, operator, right}
+	}
+	return expr, nil
+}
+
+// represents the bitwise and rule of the grammar
+// bitwise_and -> shift ( "&" shift )*
+func (p *Parser) bitwiseAnd() (Expr, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(AMPERSAND) {
+		operator := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{expr, operator, right}
+	}
+	return expr, nil
+}
+
+// represents the shift rule of the grammar
+// shift -> term ( ( "<<" | ">>" ) term )*
+func (p *Parser) shift() (Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(LESS_LESS, GREATER_GREATER) {
 		operator := p.previous()
 		right, err := p.term()
 		if err != nil {
@@ -486,14 +1573,14 @@ func (p *Parser) term() (Expr, error) {
 }
 
 // represents the factor rule of the grammar
-// factor -> unary ( ( "/" | "*" ) unary )*
+// factor -> unary ( ( "/" | "*" | "%" ) unary )*
 func (p *Parser) factor() (Expr, error) {
 	expr, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, STAR, PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -505,9 +1592,30 @@ func (p *Parser) factor() (Expr, error) {
 }
 
 // represents the unary rule of the grammar
-// unary -> ( "!" | "-" ) unary | primary
+// unary -> ( "!" | "-" | "~" ) unary | ( "++" | "--" ) unary
+// | "await" unary | "spawn" call | exponent
 func (p *Parser) unary() (Expr, error) {
-	if p.match(BANG, MINUS) {
+	if p.match(AWAIT) {
+		keyword := p.previous()
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Await{keyword, value}, nil
+	}
+	if p.match(SPAWN) {
+		keyword := p.previous()
+		expr, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := expr.(*Call)
+		if !ok {
+			return nil, p.error(keyword, "Expect function call after 'spawn'.")
+		}
+		return &Spawn{keyword, call}, nil
+	}
+	if p.match(BANG, MINUS, TILDE) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -515,17 +1623,72 @@ func (p *Parser) unary() (Expr, error) {
 		}
 		return &Unary{operator: operator, right: right}, nil
 	}
-	return p.call()
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isAssignable(target) {
+			return nil, p.error(operator, "Invalid increment target.")
+		}
+		return &Update{target: target, operator: operator, prefix: true}, nil
+	}
+	return p.exponent()
+}
+
+// represents the exponent rule of the grammar. Exponentiation is right
+// associative and binds tighter than a unary operator on its left, so
+// -2 ** 2 is -(2 ** 2) while 2 ** -1 is still allowed
+// exponent -> postfix ( "**" unary )?
+func (p *Parser) exponent() (Expr, error) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Binary{expr, operator, right}
+	}
+	return expr, nil
+}
+
+// represents the postfix rule of the grammar
+// postfix -> call ( "++" | "--" )?
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		operator := p.previous()
+		if !p.isAssignable(expr) {
+			return nil, p.error(operator, "Invalid increment target.")
+		}
+		expr = &Update{target: expr, operator: operator, prefix: false}
+	}
+	return expr, nil
 }
 
-// represents the call rule of the grammer
-// call -> primary ( "(" arguments? ")" | "." IDENTIFIER )* ;
+// represents the call rule of the grammer. If any property in the chain is
+// accessed with "?." the whole chain is wrapped so that it short-circuits
+// to nil when that property's object is nil
+// call -> primary ( "(" arguments? ")" | ( "." | "?." ) IDENTIFIER
+// | "[" expression "]" )* ;
 func (p *Parser) call() (Expr, error) {
 	expr, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
 
+	optional := false
+
 	for {
 		if p.match(LEFT_PAREN) {
 			expr, err = p.finishCall(expr)
@@ -533,31 +1696,71 @@ func (p *Parser) call() (Expr, error) {
 				return nil, err
 			}
 		} else if p.match(DOT) {
-			name, err := p.consume(IDENTIFIER, "Expect property name after '.'.")
+			name, err := p.propertyName("Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Get{expr, name, false}
+		} else if p.match(QUESTION_DOT) {
+			name, err := p.propertyName("Expect property name after '?.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Get{expr, name, true}
+			optional = true
+		} else if p.match(LEFT_BRACKET) {
+			index, err := p.expression()
 			if err != nil {
 				return nil, err
 			}
-			expr = &Get{expr, name}
+			bracket, err := p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Index{expr, bracket, index}
 		} else {
 			break
 		}
 	}
 
+	if optional {
+		expr = &OptionalChain{expr}
+	}
 	return expr, nil
 }
 
+// finishCall parses the arguments of a call after the opening "("
+// arguments -> argument ( "," argument )* ;
+// argument -> expression | IDENTIFIER ":" expression ;
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	var arguments []Expr
+	var named []NamedArgument
 	if !p.check(RIGHT_PAREN) {
 		for {
-			if len(arguments) >= 255 {
+			if len(arguments)+len(named) >= 255 {
 				p.error(p.peek(), "Can't have more than 255 arguements.")
 			}
-			value, err := p.expression()
-			if err != nil {
-				return nil, err
+
+			// an argument that starts with "name:" is passed by name
+			if p.check(IDENTIFIER) && p.checkNext(COLON) {
+				name := p.advance()
+				p.advance()
+				value, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				named = append(named, NamedArgument{name, value})
+			} else {
+				if len(named) > 0 {
+					p.error(p.peek(), "Positional arguments must come before named arguments.")
+				}
+				value, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				arguments = append(arguments, value)
 			}
-			arguments = append(arguments, value)
+
 			if !p.match(COMMA) {
 				break
 			}
@@ -569,12 +1772,14 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 		return nil, err
 	}
 
-	return &Call{callee: callee, paren: paren, arguments: arguments}, nil
+	return &Call{callee: callee, paren: paren, arguments: arguments, named: named}, nil
 }
 
 // represents the primary rule of the grammar
 // primary     -> "true" | "false" | "nil" | "this"	| NUMBER | STRING |
-// IDENTIFIER | "(" expression ")" | "super" "." IDENTIFIER ;
+// IDENTIFIER | "(" expression ")" | "super" "." IDENTIFIER |
+// "[" ( expression ( "," expression )* )? "]" |
+// "{" ( entry ( "," entry )* )? "}" ;
 func (p *Parser) primary() (Expr, error) {
 	if p.match(FALSE) {
 		return &Literal{false}, nil
@@ -609,13 +1814,89 @@ func (p *Parser) primary() (Expr, error) {
 			return nil, err
 		}
 		return &Grouping{expr}, nil
+	} else if p.match(LEFT_BRACKET) {
+		return p.list()
+	} else if p.match(LEFT_BRACE) {
+		return p.mapLiteral()
 	}
 	err := p.error(p.peek(), "Expect expression.")
 	return nil, err
 }
 
+// list parses the elements of a list literal after the opening "["
+func (p *Parser) list() (Expr, error) {
+	var elements []Expr
+	if !p.check(RIGHT_BRACKET) {
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	bracket, err := p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+	return &List{bracket, elements}, nil
+}
+
+// mapLiteral parses the entries of a map literal after the opening "{"
+// entry -> expression ":" expression ;
+func (p *Parser) mapLiteral() (Expr, error) {
+	var keys []Expr
+	var values []Expr
+	if !p.check(RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	brace, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+	return &Map{brace, keys, values}, nil
+}
+
 // consume consumes the current token if it is of the provided type
 // otherwise it will throw an error
+// propertyName consumes the name of a property after a "." The "with"
+// keyword is allowed as well so that the with() method of records can be
+// called, along with the names of private members
+func (p *Parser) propertyName(message string) (Token, error) {
+	if p.match(WITH) {
+		name := p.previous()
+		name.tokenType = IDENTIFIER
+		return name, nil
+	}
+	if p.match(PRIVATE_NAME) {
+		return p.previous(), nil
+	}
+	return p.consume(IDENTIFIER, message)
+}
+
 func (p *Parser) consume(tokenType TokenType, message string) (Token, error) {
 	if p.check(tokenType) {
 		return p.advance(), nil
@@ -644,7 +1925,7 @@ func (p *Parser) synchonize() {
 		}
 
 		switch p.peek().tokenType {
-		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN:
+		case AT, CLASS, RECORD, ENUM, FUN, VAR, CONST, LET, FOR, IF, WHILE, PRINT, RETURN, TRAIT, INTERFACE, ASYNC, MATCH:
 			return
 		}
 		p.advance()
@@ -692,6 +1973,15 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().tokenType == tokenType
 }
 
+// checkNext returns true if the token after the current one is of the
+// provided type without consuming anything
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].tokenType == EOF {
+		return false
+	}
+	return p.tokens[p.current+1].tokenType == tokenType
+}
+
 // checks if we have run out of tokens to parse
 func (p *Parser) isAtEnd() bool {
 	return p.peek().tokenType == EOF
@@ -0,0 +1,7 @@
+package main
+
+import "testing"
+
+func TestRecordsCantHaveRestComponents(t *testing.T) {
+	expectCompileError(t, `record Q(x = 5, ...rest);`, "A record can't have a rest component.")
+}
@@ -0,0 +1,88 @@
+package main
+
+// Pattern is the left hand side of a destructuring declaration or
+// assignment, describing how to take a value apart
+type Pattern interface {
+	isPattern()
+}
+
+// NamePattern binds a value to a new variable in a declaration
+type NamePattern struct {
+	name Token
+}
+
+// TargetPattern stores a value into a variable, property or index in an
+// assignment
+type TargetPattern struct {
+	target Expr
+}
+
+// ListPattern takes a list apart by position, as in "var [a, b] = list;".
+// Elements past the end of the list are nil, and rest, if there is one,
+// collects the elements that weren't matched into a new list
+type ListPattern struct {
+	bracket  Token
+	elements []PatternElement
+	rest     *Token
+}
+
+// ObjectPattern reads properties from an instance or keys from a map, as in
+// "var {x, y} = point;"
+type ObjectPattern struct {
+	brace      Token
+	properties []PropertyPattern
+}
+
+// PatternElement is one position of a list pattern. The default value is
+// used when the element is nil
+type PatternElement struct {
+	target       Pattern
+	defaultValue Expr
+}
+
+// PropertyPattern is one property of an object pattern. The value is bound
+// to a variable with the property's name unless a target is given with
+// "name: pattern". The default value is used when the property is nil or
+// missing
+type PropertyPattern struct {
+	name         Token
+	target       Pattern
+	defaultValue Expr
+}
+
+// WildcardPattern is the "_" pattern of a match case, which matches any
+// value without binding it
+type WildcardPattern struct {
+	underscore Token
+}
+
+// ValuePattern matches a value equal to the result of an expression. A
+// range matches the numbers in it and a class matches its instances
+type ValuePattern struct {
+	value Expr
+}
+
+// ClassPattern matches instances of a class whose properties also match,
+// as in "Point{x: 0, y}"
+type ClassPattern struct {
+	class  *Variable
+	object *ObjectPattern
+}
+
+// MatchCase is one case of a match statement. The body runs for the first
+// case with a pattern that matches and a guard, if there is one, that is true
+type MatchCase struct {
+	keyword  Token
+	patterns []Pattern
+	guard    Expr
+	body     Stmt
+}
+
+func (p *NamePattern) isPattern()   {}
+func (p *TargetPattern) isPattern() {}
+func (p *ListPattern) isPattern()   {}
+func (p *ObjectPattern) isPattern() {}
+
+func (p *WildcardPattern) isPattern() {}
+func (p *ValuePattern) isPattern()    {}
+func (p *ClassPattern) isPattern()    {}
@@ -1,7 +1,9 @@
 package main
 
 type Resolver struct {
-	scopes          stack[map[string]bool]
+	scopes stack[map[string]bool]
+	// the names declared with const or let in each of the scopes
+	constants       stack[map[string]bool]
 	currentFunction FunctionType
 	currentClass    ClassType
 	interpreter     *Interpreter
@@ -14,6 +16,7 @@ const (
 	FUNCTION_FUNCTION
 	FUNCTION_INITIALIZER
 	FUNCTION_METHOD
+	FUNCTION_ASYNC
 )
 
 type ClassType int
@@ -22,18 +25,21 @@ const (
 	CLASS_NONE ClassType = iota
 	CLASS_CLASS
 	CLASS_SUBCLASS
+	CLASS_TRAIT
 )
 
 func NewResolver(interpreter *Interpreter) *Resolver {
-	return &Resolver{interpreter: interpreter, currentFunction: FUNCTION_NONE, currentClass: CLASS_NONE, scopes: *NewStack[map[string]bool]()}
+	return &Resolver{interpreter: interpreter, currentFunction: FUNCTION_NONE, currentClass: CLASS_NONE, scopes: *NewStack[map[string]bool](), constants: *NewStack[map[string]bool]()}
 }
 
 func (r *Resolver) endScope() {
 	r.scopes.Pop()
+	r.constants.Pop()
 }
 
 func (r *Resolver) beginScope() {
 	r.scopes.Push(make(map[string]bool))
+	r.constants.Push(make(map[string]bool))
 }
 
 func (r *Resolver) define(name Token) {
@@ -63,6 +69,11 @@ func (r *Resolver) error(token Token, message string) {
 	}
 }
 
+// warning prints a warning to the console without stopping the program
+func (r *Resolver) warning(token Token, message string) {
+	reportWarning(token.line, " at '"+token.lexeme+"'", message)
+}
+
 func (r *Resolver) resolveStatements(statements []Stmt) {
 	for _, statement := range statements {
 		r.resolveStatement(statement)
@@ -87,27 +98,57 @@ func (r *Resolver) resolveLocal(expr Expr, name Token) {
 	}
 }
 
+// checkAssignable reports an assignment to a local constant. Global
+// constants are checked by the interpreter instead
+func (r *Resolver) checkAssignable(name Token) {
+	for i := r.scopes.Len() - 1; i >= 0; i-- {
+		if _, present := r.scopes.get(i)[name.lexeme]; present {
+			if r.constants.get(i)[name.lexeme] {
+				r.error(name, "Can't assign to constant '"+name.lexeme+"'.")
+			}
+			return
+		}
+	}
+}
+
 func (r *Resolver) VisitExpressionStmt(stmt *Expression) (interface{}, error) {
 	r.resolveExpression(stmt.expression)
 	return nil, nil
 }
 
 func (r *Resolver) VisitFunctionStmt(stmt *Function) (interface{}, error) {
+	r.resolveDecorators(stmt.decorators)
 	r.declare(stmt.name)
 	r.define(stmt.name)
 
-	r.resolveFunction(stmt, FUNCTION_FUNCTION)
+	if stmt.async {
+		r.resolveFunction(stmt, FUNCTION_ASYNC)
+	} else {
+		r.resolveFunction(stmt, FUNCTION_FUNCTION)
+	}
 	return nil, nil
 }
 
+// decorators are evaluated in the scope around the declaration, before its
+// name is defined
+func (r *Resolver) resolveDecorators(decorators []Decorator) {
+	for _, decorator := range decorators {
+		r.resolveExpression(decorator.expression)
+	}
+}
+
 func (r *Resolver) resolveFunction(function *Function, funcType FunctionType) {
 	var enclosingFunction FunctionType = r.currentFunction
 	r.currentFunction = funcType
 
 	r.beginScope()
 	for _, param := range function.params {
-		r.declare(param)
-		r.define(param)
+		// a default can refer to the parameters before it but not itself
+		if param.defaultValue != nil {
+			r.resolveExpression(param.defaultValue)
+		}
+		r.declare(param.name)
+		r.define(param.name)
 	}
 	r.resolveStatements(function.body)
 	r.endScope()
@@ -148,15 +189,129 @@ func (r *Resolver) VisitVarStmt(stmt *Var) (interface{}, error) {
 		r.resolveExpression(stmt.initializer)
 	}
 	r.define(stmt.name)
+	if stmt.constant && !r.scopes.isEmpty() {
+		r.constants.Peek()[stmt.name.lexeme] = true
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitDestructureVarStmt(stmt *DestructureVar) (interface{}, error) {
+	r.resolveExpression(stmt.initializer)
+	r.resolvePattern(stmt.pattern, stmt.constant)
+	return nil, nil
+}
+
+func (r *Resolver) VisitDestructureExpr(expr *Destructure) (interface{}, error) {
+	r.resolveExpression(expr.value)
+	r.resolvePattern(expr.pattern, false)
+	return nil, nil
+}
+
+// resolvePattern declares each name bound by a pattern in the current scope,
+// in order, so a default can refer to the names before it. Targets of
+// assignments are resolved like any other expression
+func (r *Resolver) resolvePattern(pattern Pattern, constant bool) {
+	switch pattern := pattern.(type) {
+	case *NamePattern:
+		r.declare(pattern.name)
+		r.define(pattern.name)
+		if constant && !r.scopes.isEmpty() {
+			r.constants.Peek()[pattern.name.lexeme] = true
+		}
+	case *TargetPattern:
+		if variable, ok := pattern.target.(*Variable); ok {
+			r.checkAssignable(variable.name)
+		}
+		r.resolveExpression(pattern.target)
+	case *ListPattern:
+		for _, element := range pattern.elements {
+			if element.defaultValue != nil {
+				r.resolveExpression(element.defaultValue)
+			}
+			r.resolvePattern(element.target, constant)
+		}
+		if pattern.rest != nil {
+			r.resolvePattern(&NamePattern{*pattern.rest}, constant)
+		}
+	case *ObjectPattern:
+		for _, property := range pattern.properties {
+			if property.defaultValue != nil {
+				r.resolveExpression(property.defaultValue)
+			}
+			r.resolvePattern(property.target, constant)
+		}
+	}
+}
+
+// VisitMatchStmt resolves each case in its own scope. A case can never be
+// reached after a case that matches everything, which is only a warning
+func (r *Resolver) VisitMatchStmt(stmt *Match) (interface{}, error) {
+	r.resolveExpression(stmt.subject)
+	unreachable := false
+	for _, matchCase := range stmt.cases {
+		if unreachable {
+			r.warning(matchCase.keyword, "Unreachable case after a wildcard.")
+		}
+		r.beginScope()
+		for _, pattern := range matchCase.patterns {
+			r.resolveMatchPattern(pattern)
+			switch pattern.(type) {
+			case *WildcardPattern, *NamePattern:
+				if matchCase.guard == nil {
+					unreachable = true
+				}
+			}
+		}
+		if matchCase.guard != nil {
+			r.resolveExpression(matchCase.guard)
+		}
+		r.resolveStatement(matchCase.body)
+		r.endScope()
+	}
 	return nil, nil
 }
 
+// resolveMatchPattern defines the names bound by a match pattern. The
+// alternatives of a case share a scope so they can bind the same names
+func (r *Resolver) resolveMatchPattern(pattern Pattern) {
+	switch pattern := pattern.(type) {
+	case *NamePattern:
+		r.define(pattern.name)
+	case *ValuePattern:
+		r.resolveExpression(pattern.value)
+	case *ClassPattern:
+		r.resolveExpression(pattern.class)
+		r.resolveMatchPattern(pattern.object)
+	case *ListPattern:
+		for _, element := range pattern.elements {
+			r.resolveMatchPattern(element.target)
+		}
+		if pattern.rest != nil {
+			r.define(*pattern.rest)
+		}
+	case *ObjectPattern:
+		for _, property := range pattern.properties {
+			r.resolveMatchPattern(property.target)
+		}
+	}
+}
+
 func (r *Resolver) VisitWhileStmt(stmt *While) (interface{}, error) {
 	r.resolveExpression(stmt.condition)
 	r.resolveStatement(stmt.body)
 	return nil, nil
 }
 
+func (r *Resolver) VisitForInStmt(stmt *ForIn) (interface{}, error) {
+	r.resolveExpression(stmt.iterable)
+	r.beginScope()
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	r.resolveStatement(stmt.body)
+	r.endScope()
+	return nil, nil
+}
+
 func (r *Resolver) VisitBlockStmt(stmt *Block) (interface{}, error) {
 	r.beginScope()
 	r.resolveStatements(stmt.statements)
@@ -169,6 +324,7 @@ func (r *Resolver) VisitClassStmt(stmt *Class) (interface{}, error) {
 	r.currentClass = CLASS_CLASS
 	defer func() { r.currentClass = enclosingClass }()
 
+	r.resolveDecorators(stmt.decorators)
 	r.declare(stmt.name)
 	r.define(stmt.name)
 
@@ -188,15 +344,41 @@ func (r *Resolver) VisitClassStmt(stmt *Class) (interface{}, error) {
 		r.resolveExpression(stmt.superclass)
 	}
 
+	for _, trait := range stmt.traits {
+		r.resolveExpression(trait)
+	}
+
+	for _, iface := range stmt.interfaces {
+		r.resolveExpression(iface)
+	}
+
 	// if the class definition has a superclass, then we create a new scope
 	// surrounding all of its methods. In that scope, we define the name "super"
 	// Once we're done resolving the classes methods, we discard the scope
 	if stmt.superclass != nil {
 		r.beginScope()
 		r.scopes.Peek()["super"] = true
-		defer r.endScope()
 	}
 
+	// method decorators are evaluated when the class is created, outside
+	// of the scope defining "this"
+	for _, method := range stmt.methods {
+		r.resolveDecorators(method.decorators)
+	}
+	for _, method := range stmt.classMethods {
+		r.resolveDecorators(method.decorators)
+		if method.name.tokenType == PRIVATE_NAME {
+			r.error(method.name, "Class methods can't be private.")
+		}
+		r.checkStaticName(method.name)
+	}
+	for _, field := range stmt.classFields {
+		r.checkStaticName(field.name)
+	}
+
+	// the scope holding the class, which private members are checked against
+	r.beginScope()
+	r.scopes.Peek()["#class"] = true
 	r.beginScope()
 	r.scopes.Peek()["this"] = true
 
@@ -208,13 +390,123 @@ func (r *Resolver) VisitClassStmt(stmt *Class) (interface{}, error) {
 		r.resolveFunction(&method, declaration)
 	}
 
+	// inside a class method "this" refers to the class itself, so it is
+	// resolved the same way as an ordinary method
+	for _, method := range stmt.classMethods {
+		r.resolveFunction(&method, FUNCTION_METHOD)
+	}
+
+	// instance field initializers run with "this" bound to the new instance
+	// before init is called, so they are checked like an initializer
+	var enclosingFunction FunctionType = r.currentFunction
+	r.currentFunction = FUNCTION_INITIALIZER
+	for _, field := range stmt.fields {
+		if field.initializer != nil {
+			r.resolveExpression(field.initializer)
+		}
+	}
+	r.currentFunction = enclosingFunction
+
+	r.endScope()
 	r.endScope()
 
+	if stmt.superclass != nil {
+		r.endScope()
+	}
+
+	// static field initializers run in the scope surrounding the class
+	r.currentClass = enclosingClass
+	for _, field := range stmt.classFields {
+		if field.initializer != nil {
+			r.resolveExpression(field.initializer)
+		}
+	}
+
+	return nil, nil
+}
+
+// reservedStaticNames are the built-in properties of every class, which
+// would hide a static field or class method with the same name
+var reservedStaticNames = map[string]bool{
+	"name":       true,
+	"superclass": true,
+}
+
+func (r *Resolver) checkStaticName(name Token) {
+	if reservedStaticNames[name.lexeme] {
+		r.error(name, "Can't use '"+name.lexeme+"' as a static member name.")
+	}
+}
+
+// enum methods are resolved like the methods of a class without a
+// superclass. The members are created by the enum so it has no initializer
+func (r *Resolver) VisitEnumStmt(stmt *Enum) (interface{}, error) {
+	var enclosingClass ClassType = r.currentClass
+	r.currentClass = CLASS_CLASS
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	r.beginScope()
+	r.scopes.Peek()["#class"] = true
+	r.beginScope()
+	r.scopes.Peek()["this"] = true
+	for _, method := range stmt.methods {
+		if method.name.lexeme == "init" {
+			r.error(method.name, "An enum can't have an initializer.")
+		}
+		r.resolveFunction(&method, FUNCTION_METHOD)
+	}
+	r.endScope()
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterfaceStmt(stmt *Interface) (interface{}, error) {
+	r.declare(stmt.name)
+	r.define(stmt.name)
+	return nil, nil
+}
+
+// trait methods are resolved like the methods of a subclass. The "super"
+// scope matches the environment each class using the trait creates to hold
+// its superclass when the trait's methods are mixed in
+func (r *Resolver) VisitTraitStmt(stmt *Trait) (interface{}, error) {
+	var enclosingClass ClassType = r.currentClass
+	r.currentClass = CLASS_TRAIT
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	r.beginScope()
+	r.scopes.Peek()["super"] = true
+	r.beginScope()
+	r.scopes.Peek()["#class"] = true
+	r.beginScope()
+	r.scopes.Peek()["this"] = true
+
+	for _, method := range stmt.methods {
+		if method.name.tokenType == PRIVATE_NAME {
+			r.error(method.name, "Trait methods can't be private.")
+		}
+		declaration := FUNCTION_METHOD
+		if method.name.lexeme == "init" {
+			declaration = FUNCTION_INITIALIZER
+		}
+		r.resolveFunction(&method, declaration)
+	}
+
+	r.endScope()
+	r.endScope()
+	r.endScope()
 	return nil, nil
 }
 
 func (r *Resolver) VisitAssignExpr(expr *Assign) (interface{}, error) {
 	r.resolveExpression(expr.value)
+	r.checkAssignable(expr.name)
 	r.resolveLocal(expr, expr.name)
 	return nil, nil
 }
@@ -230,14 +522,125 @@ func (r *Resolver) VisitCallExpr(expr *Call) (interface{}, error) {
 	for _, argument := range expr.arguments {
 		r.resolveExpression(argument)
 	}
+	for _, argument := range expr.named {
+		r.resolveExpression(argument.value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitCompoundExpr(expr *Compound) (interface{}, error) {
+	r.resolveExpression(expr.value)
+	if variable, ok := expr.target.(*Variable); ok {
+		r.checkAssignable(variable.name)
+	}
+	r.resolveExpression(expr.target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUpdateExpr(expr *Update) (interface{}, error) {
+	if variable, ok := expr.target.(*Variable); ok {
+		r.checkAssignable(variable.name)
+	}
+	r.resolveExpression(expr.target)
+	return nil, nil
+}
+
+func (r *Resolver) VisitListExpr(expr *List) (interface{}, error) {
+	for _, element := range expr.elements {
+		r.resolveExpression(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *Map) (interface{}, error) {
+	for i := range expr.keys {
+		r.resolveExpression(expr.keys[i])
+		r.resolveExpression(expr.values[i])
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitRangeExpr(expr *Range) (interface{}, error) {
+	r.resolveExpression(expr.start)
+	r.resolveExpression(expr.end)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSpawnExpr(expr *Spawn) (interface{}, error) {
+	r.resolveExpression(expr.call)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAwaitExpr(expr *Await) (interface{}, error) {
+	if r.currentFunction != FUNCTION_ASYNC {
+		r.error(expr.keyword, "Can't use 'await' outside of an async function.")
+	}
+	r.resolveExpression(expr.value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitYieldExpr(expr *Yield) (interface{}, error) {
+	if r.currentFunction == FUNCTION_NONE {
+		r.error(expr.keyword, "Can't yield from top-level code.")
+	}
+	if r.currentFunction == FUNCTION_INITIALIZER {
+		r.error(expr.keyword, "Can't yield from an initializer.")
+	}
+	if r.currentFunction == FUNCTION_ASYNC {
+		r.error(expr.keyword, "Can't yield from an async function.")
+	}
+
+	if expr.value != nil {
+		r.resolveExpression(expr.value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(expr *Index) (interface{}, error) {
+	r.resolveExpression(expr.object)
+	r.resolveExpression(expr.index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) {
+	r.resolveExpression(expr.value)
+	r.resolveExpression(expr.object)
+	r.resolveExpression(expr.index)
 	return nil, nil
 }
 
 func (r *Resolver) VisitGetExpr(expr *Get) (interface{}, error) {
+	r.checkPrivate(expr.name)
 	r.resolveExpression(expr.object)
 	return nil, nil
 }
 
+// checkPrivate reports private members used outside of the methods of a
+// class. Whether the object belongs to the class is checked at runtime
+func (r *Resolver) checkPrivate(name Token) {
+	if name.tokenType != PRIVATE_NAME {
+		return
+	}
+	switch r.currentClass {
+	case CLASS_NONE:
+		r.error(name, "Can't use private member '"+name.lexeme+"' outside of a class.")
+	case CLASS_TRAIT:
+		r.error(name, "Can't use private members in a trait.")
+	}
+}
+
+func (r *Resolver) VisitConditionalExpr(expr *Conditional) (interface{}, error) {
+	r.resolveExpression(expr.condition)
+	r.resolveExpression(expr.thenBranch)
+	r.resolveExpression(expr.elseBranch)
+	return nil, nil
+}
+
+func (r *Resolver) VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error) {
+	r.resolveExpression(expr.expression)
+	return nil, nil
+}
+
 func (r *Resolver) VisitGroupingExpr(expr *Grouping) (interface{}, error) {
 	r.resolveExpression(expr.expression)
 	return nil, nil
@@ -254,6 +657,7 @@ func (r *Resolver) VisitLogicalExpr(expr *Logical) (interface{}, error) {
 }
 
 func (r *Resolver) VisitSetExpr(expr *Set) (interface{}, error) {
+	r.checkPrivate(expr.name)
 	r.resolveExpression(expr.value)
 	r.resolveExpression(expr.object)
 	return nil, nil
@@ -266,7 +670,7 @@ func (r *Resolver) VisitSuperExpr(expr *Super) (interface{}, error) {
 	// check to see if currently inside of a subclass
 	if r.currentClass == CLASS_NONE {
 		r.error(expr.keyword, "Can't use 'super' outside of a class.")
-	} else if r.currentClass != CLASS_SUBCLASS {
+	} else if r.currentClass != CLASS_SUBCLASS && r.currentClass != CLASS_TRAIT {
 		r.error(expr.keyword, "Can't use 'super' in a class with no superclass.")
 	}
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 )
 
 type Scanner struct {
@@ -27,22 +28,36 @@ type Scanner struct {
 func NewScanner(source string) *Scanner {
 	s := &Scanner{source: source, tokens: []Token{}, start: 0, current: 0, line: 1}
 	s.keywords = map[string]TokenType{
-		"and":    AND,
-		"class":  CLASS,
-		"else":   ELSE,
-		"false":  FALSE,
-		"for":    FOR,
-		"fun":    FUN,
-		"if":     IF,
-		"nil":    NIL,
-		"or":     OR,
-		"print":  PRINT,
-		"return": RETURN,
-		"super":  SUPER,
-		"this":   THIS,
-		"true":   TRUE,
-		"var":    VAR,
-		"while":  WHILE,
+		"and":       AND,
+		"async":     ASYNC,
+		"await":     AWAIT,
+		"case":      CASE,
+		"class":     CLASS,
+		"const":     CONST,
+		"else":      ELSE,
+		"enum":      ENUM,
+		"false":     FALSE,
+		"for":       FOR,
+		"fun":       FUN,
+		"if":        IF,
+		"in":        IN,
+		"interface": INTERFACE,
+		"let":       LET,
+		"match":     MATCH,
+		"nil":       NIL,
+		"or":        OR,
+		"print":     PRINT,
+		"record":    RECORD,
+		"return":    RETURN,
+		"spawn":     SPAWN,
+		"super":     SUPER,
+		"this":      THIS,
+		"trait":     TRAIT,
+		"true":      TRUE,
+		"var":       VAR,
+		"while":     WHILE,
+		"with":      WITH,
+		"yield":     YIELD,
 	}
 	return s
 }
@@ -82,18 +97,78 @@ func (s *Scanner) scanToken() {
 		s.addToken(LEFT_BRACE)
 	case '}':
 		s.addToken(RIGHT_BRACE)
+	case '[':
+		s.addToken(LEFT_BRACKET)
+	case ']':
+		s.addToken(RIGHT_BRACKET)
 	case ',':
 		s.addToken(COMMA)
 	case '.':
-		s.addToken(DOT)
+		if s.match('.') {
+			if s.match('<') {
+				s.addToken(DOT_DOT_LESS)
+			} else if s.match('.') {
+				s.addToken(DOT_DOT_DOT)
+			} else {
+				s.addToken(DOT_DOT)
+			}
+		} else {
+			s.addToken(DOT)
+		}
 	case '-':
-		s.addToken(MINUS)
+		if s.match('=') {
+			s.addToken(MINUS_EQUAL)
+		} else if s.match('-') {
+			s.addToken(MINUS_MINUS)
+		} else {
+			s.addToken(MINUS)
+		}
 	case '+':
-		s.addToken(PLUS)
+		if s.match('=') {
+			s.addToken(PLUS_EQUAL)
+		} else if s.match('+') {
+			s.addToken(PLUS_PLUS)
+		} else {
+			s.addToken(PLUS)
+		}
 	case ';':
 		s.addToken(SEMICOLON)
 	case '*':
-		s.addToken(STAR)
+		if s.match('*') {
+			s.addToken(STAR_STAR)
+		} else if s.match('=') {
+			s.addToken(STAR_EQUAL)
+		} else {
+			s.addToken(STAR)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(PERCENT_EQUAL)
+		} else {
+			s.addToken(PERCENT)
+		}
+	case '&':
+		s.addToken(AMPERSAND)
+	case '|':
+		s.addToken(PIPE)
+	case '^':
+		s.addToken(CARET)
+	case '~':
+		s.addToken(TILDE)
+	case ':':
+		s.addToken(COLON)
+	case '@':
+		s.addToken(AT)
+	case '#':
+		s.privateName()
+	case '?':
+		if s.match('?') {
+			s.addToken(QUESTION_QUESTION)
+		} else if s.match('.') {
+			s.addToken(QUESTION_DOT)
+		} else {
+			s.addToken(QUESTION)
+		}
 	case '!':
 		if s.match('=') {
 			s.addToken(BANG_EQUAL)
@@ -103,27 +178,41 @@ func (s *Scanner) scanToken() {
 	case '=':
 		if s.match('=') {
 			s.addToken(EQUAL_EQUAL)
+		} else if s.match('>') {
+			s.addToken(ARROW)
 		} else {
 			s.addToken(EQUAL)
 		}
 	case '<':
 		if s.match('=') {
 			s.addToken(LESS_EQUAL)
+		} else if s.match('<') {
+			s.addToken(LESS_LESS)
 		} else {
 			s.addToken(LESS)
 		}
 	case '>':
 		if s.match('=') {
 			s.addToken(GREATER_EQUAL)
+		} else if s.match('>') {
+			s.addToken(GREATER_GREATER)
 		} else {
 			s.addToken(GREATER)
 		}
 	case '/':
 		if s.match('/') {
+			if s.peek() == '/' && s.peekNext() != '/' {
+				s.docComment()
+				break
+			}
 			// We are at a comment, consume the rest of the line
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+		} else if s.match('*') {
+			s.blockComment()
+		} else if s.match('=') {
+			s.addToken(SLASH_EQUAL)
 		} else {
 			s.addToken(SLASH)
 		}
@@ -162,6 +251,49 @@ func (s *Scanner) identifier() {
 	s.addToken(tokenType)
 }
 
+// privateName consumes the name of a private member such as "#count"
+func (s *Scanner) privateName() {
+	if !s.isAlpha(s.peek()) {
+		report(s.line, "", "Expect name after '#'.")
+		return
+	}
+	for s.isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	s.addToken(PRIVATE_NAME)
+}
+
+// docComment consumes a "///" comment and adds its text as a token so the
+// parser can attach it to the declaration that follows
+func (s *Scanner) docComment() {
+	for s.peek() != '\n' && !s.isAtEnd() {
+		s.advance()
+	}
+	text := strings.TrimRight(s.source[s.start+3:s.current], " \t\r")
+	s.addTokenLiteral(DOC_COMMENT, strings.TrimPrefix(text, " "))
+}
+
+// blockComment consumes a "/* */" comment. Block comments can be nested so
+// the comment only ends once every "/*" inside it has been closed
+func (s *Scanner) blockComment() {
+	line := s.line
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			report(line, "", "Unterminated block comment.")
+			return
+		}
+		c := s.advance()
+		if c == '\n' {
+			s.line++
+		} else if c == '/' && s.match('*') {
+			depth++
+		} else if c == '*' && s.match('/') {
+			depth--
+		}
+	}
+}
+
 // isAlphaNumber returns true if the character is a valid alpha character or
 // digit for a lox identifier
 func (s *Scanner) isAlphaNumeric(c byte) bool {
@@ -182,31 +314,142 @@ func (s *Scanner) isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
-// number will consume as many digits as found for the integer part of the literal
-// it will look for a decimal point that is followed by at least one digit
-// if a fractional part exists it will then consume any lettes found after
+// number consumes a number literal. Decimal literals can have a fractional
+// part and an exponent, and a "0x", "0b" or "0o" prefix starts a hexadecimal,
+// binary or octal integer. Digits in any of them can be separated with '_'
 func (s *Scanner) number() {
-	for s.isDigit(s.peek()) {
-		s.advance()
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.radixNumber(16, "hexadecimal", isHexDigit)
+			return
+		case 'b', 'B':
+			s.radixNumber(2, "binary", isBinaryDigit)
+			return
+		case 'o', 'O':
+			s.radixNumber(8, "octal", isOctalDigit)
+			return
+		}
 	}
 
+	s.digits(s.isDigit)
+
 	// look for a fractional part of the number
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
 		//consume the decimal point
 		s.advance()
-		for s.isDigit(s.peek()) {
+		s.digits(s.isDigit)
+	}
+
+	// look for an exponent such as "e10" or "E-3"
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		if !s.isDigit(s.peek()) {
+			s.malformedNumber("Expect digits in exponent.")
+			return
+		}
+		s.digits(s.isDigit)
 	}
 
-	value, err := strconv.ParseFloat(s.source[s.start:s.current], 64)
+	if s.isAlpha(s.peek()) && s.peek() != '_' {
+		s.malformedNumber("Invalid character '" + string(s.peek()) + "' in number.")
+		return
+	}
+
+	text := s.source[s.start:s.current]
+	if !separatorsValid(text, s.isDigit) {
+		s.malformedNumber("Digit separator '_' must be between digits.")
+		return
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
 	if err != nil {
-		report(s.line, "", "Invalid number.")
+		s.malformedNumber("Number literal is too large.")
+		return
+	}
+	s.addTokenLiteral(NUMBER, value)
+}
+
+// radixNumber consumes the digits of an integer literal after its prefix
+func (s *Scanner) radixNumber(base int, name string, isDigit func(byte) bool) {
+	// consume the prefix
+	s.advance()
+	digitsStart := s.current
+	s.digits(isDigit)
+
+	text := s.source[digitsStart:s.current]
+	if s.isAlphaNumeric(s.peek()) {
+		s.malformedNumber("Invalid digit '" + string(s.peek()) + "' in " + name + " number.")
+		return
+	}
+	if strings.Trim(text, "_") == "" {
+		s.malformedNumber("Expect digits after '" + s.source[s.start:digitsStart] + "'.")
 		return
 	}
+	if !separatorsValid(text, isDigit) {
+		s.malformedNumber("Digit separator '_' must be between digits.")
+		return
+	}
+
+	// accumulating into a float allows literals wider than 64 bits, which
+	// lose precision the same way large decimal literals do
+	var value float64
+	for _, digit := range strings.ReplaceAll(text, "_", "") {
+		n, _ := strconv.ParseUint(string(digit), base, 8)
+		value = value*float64(base) + float64(n)
+	}
 	s.addTokenLiteral(NUMBER, value)
 }
 
+// digits consumes a run of digits and the separators between them
+func (s *Scanner) digits(isDigit func(byte) bool) {
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
+// malformedNumber reports an error in a number literal and skips the rest
+// of it so that it isn't scanned as more tokens. A placeholder number is
+// added in its place so the parser doesn't report a second error
+func (s *Scanner) malformedNumber(message string) {
+	report(s.line, "", message)
+	for s.isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+	s.addTokenLiteral(NUMBER, 0.0)
+}
+
+// separatorsValid returns true if every '_' in the text of a number sits
+// between two digits
+func separatorsValid(text string, isDigit func(byte) bool) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(text)-1 || !isDigit(text[i-1]) || !isDigit(text[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHexDigit returns true if the character is 0-9, a-f or A-F
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// isBinaryDigit returns true if the character is 0 or 1
+func isBinaryDigit(c byte) bool {
+	return c == '0' || c == '1'
+}
+
+// isOctalDigit returns true if the character is 0-7
+func isOctalDigit(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
 // string consumes characters until it reaches the " that ends the string.
 // Will also gracefully handle running out of input until the string is
 // closed and report the error
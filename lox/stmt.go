@@ -7,11 +7,17 @@ type Stmt interface {
 type StmtVisitor interface {
 	VisitBlockStmt(stmt *Block) (interface{}, error)
 	VisitClassStmt(stmt *Class) (interface{}, error)
+	VisitDestructureVarStmt(stmt *DestructureVar) (interface{}, error)
+	VisitEnumStmt(stmt *Enum) (interface{}, error)
 	VisitExpressionStmt(stmt *Expression) (interface{}, error)
+	VisitForInStmt(stmt *ForIn) (interface{}, error)
 	VisitFunctionStmt(stmt *Function) (interface{}, error)
 	VisitIfStmt(stmt *If) (interface{}, error)
+	VisitInterfaceStmt(stmt *Interface) (interface{}, error)
+	VisitMatchStmt(stmt *Match) (interface{}, error)
 	VisitPrintStmt(stmt *Print) (interface{}, error)
 	VisitReturnStmt(stmt *Return) (interface{}, error)
+	VisitTraitStmt(stmt *Trait) (interface{}, error)
 	VisitVarStmt(stmt *Var) (interface{}, error)
 	VisitWhileStmt(stmt *While) (interface{}, error)
 }
@@ -25,15 +31,45 @@ func (b *Block) Accept(visitor StmtVisitor) (interface{}, error) {
 }
 
 type Class struct {
-	name       Token
-	superclass *Variable
-	methods    []Function
+	name         Token
+	superclass   *Variable
+	traits       []*Variable
+	interfaces   []*Variable
+	methods      []Function
+	classMethods []Function
+	fields       []Var
+	classFields  []Var
+	record       bool
+	decorators   []Decorator
+	doc          string
 }
 
 func (c *Class) Accept(visitor StmtVisitor) (interface{}, error) {
 	return visitor.VisitClassStmt(c)
 }
 
+type DestructureVar struct {
+	pattern     Pattern
+	initializer Expr
+	constant    bool
+	doc         string
+}
+
+func (d *DestructureVar) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitDestructureVarStmt(d)
+}
+
+type Enum struct {
+	name    Token
+	members []Token
+	methods []Function
+	doc     string
+}
+
+func (e *Enum) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitEnumStmt(e)
+}
+
 type Expression struct {
 	expression Expr
 }
@@ -42,10 +78,26 @@ func (e *Expression) Accept(visitor StmtVisitor) (interface{}, error) {
 	return visitor.VisitExpressionStmt(e)
 }
 
+type ForIn struct {
+	name     Token
+	keyword  Token
+	iterable Expr
+	body     Stmt
+}
+
+func (f *ForIn) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitForInStmt(f)
+}
+
 type Function struct {
-	name   Token
-	params []Token
-	body   []Stmt
+	name       Token
+	params     []Parameter
+	body       []Stmt
+	getter     bool
+	generator  bool
+	async      bool
+	decorators []Decorator
+	doc        string
 }
 
 func (f *Function) Accept(visitor StmtVisitor) (interface{}, error) {
@@ -62,6 +114,26 @@ func (i *If) Accept(visitor StmtVisitor) (interface{}, error) {
 	return visitor.VisitIfStmt(i)
 }
 
+type Interface struct {
+	name    Token
+	methods []Function
+	doc     string
+}
+
+func (i *Interface) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitInterfaceStmt(i)
+}
+
+type Match struct {
+	keyword Token
+	subject Expr
+	cases   []MatchCase
+}
+
+func (m *Match) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitMatchStmt(m)
+}
+
 type Print struct {
 	expression Expr
 }
@@ -79,9 +151,21 @@ func (r *Return) Accept(visitor StmtVisitor) (interface{}, error) {
 	return visitor.VisitReturnStmt(r)
 }
 
+type Trait struct {
+	name    Token
+	methods []Function
+	doc     string
+}
+
+func (t *Trait) Accept(visitor StmtVisitor) (interface{}, error) {
+	return visitor.VisitTraitStmt(t)
+}
+
 type Var struct {
 	name        Token
 	initializer Expr
+	constant    bool
+	doc         string
 }
 
 func (v *Var) Accept(visitor StmtVisitor) (interface{}, error) {
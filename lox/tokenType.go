@@ -9,6 +9,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -16,6 +18,13 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	COLON
+	AT
 
 	// One or two character tokens.
 	BANG
@@ -24,31 +33,64 @@ const (
 	EQUAL_EQUAL
 	GREATER
 	GREATER_EQUAL
+	GREATER_GREATER
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	STAR_STAR
+	QUESTION
+	QUESTION_DOT
+	QUESTION_QUESTION
+	PLUS_EQUAL
+	PLUS_PLUS
+	MINUS_EQUAL
+	MINUS_MINUS
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	DOT_DOT
+	DOT_DOT_LESS
+	DOT_DOT_DOT
+	ARROW
 
 	// Literals.
 	IDENTIFIER
+	PRIVATE_NAME
 	STRING
 	NUMBER
+	DOC_COMMENT
 
 	// Keywords.
 	AND
+	ASYNC
+	AWAIT
+	CASE
 	CLASS
+	CONST
 	ELSE
+	ENUM
 	FALSE
 	FUN
 	FOR
 	IF
+	IN
+	INTERFACE
+	LET
+	MATCH
 	NIL
 	OR
 	PRINT
+	RECORD
 	RETURN
+	SPAWN
 	SUPER
 	THIS
+	TRAIT
 	TRUE
 	VAR
 	WHILE
+	WITH
+	YIELD
 
 	EOF
 )
@@ -12,46 +12,88 @@ func _() {
 	_ = x[RIGHT_PAREN-1]
 	_ = x[LEFT_BRACE-2]
 	_ = x[RIGHT_BRACE-3]
-	_ = x[COMMA-4]
-	_ = x[DOT-5]
-	_ = x[MINUS-6]
-	_ = x[PLUS-7]
-	_ = x[SEMICOLON-8]
-	_ = x[SLASH-9]
-	_ = x[STAR-10]
-	_ = x[BANG-11]
-	_ = x[BANG_EQUAL-12]
-	_ = x[EQUAL-13]
-	_ = x[EQUAL_EQUAL-14]
-	_ = x[GREATER-15]
-	_ = x[GREATER_EQUAL-16]
-	_ = x[LESS-17]
-	_ = x[LESS_EQUAL-18]
-	_ = x[IDENTIFIER-19]
-	_ = x[STRING-20]
-	_ = x[NUMBER-21]
-	_ = x[AND-22]
-	_ = x[CLASS-23]
-	_ = x[ELSE-24]
-	_ = x[FALSE-25]
-	_ = x[FUN-26]
-	_ = x[FOR-27]
-	_ = x[IF-28]
-	_ = x[NIL-29]
-	_ = x[OR-30]
-	_ = x[PRINT-31]
-	_ = x[RETURN-32]
-	_ = x[SUPER-33]
-	_ = x[THIS-34]
-	_ = x[TRUE-35]
-	_ = x[VAR-36]
-	_ = x[WHILE-37]
-	_ = x[EOF-38]
+	_ = x[LEFT_BRACKET-4]
+	_ = x[RIGHT_BRACKET-5]
+	_ = x[COMMA-6]
+	_ = x[DOT-7]
+	_ = x[MINUS-8]
+	_ = x[PLUS-9]
+	_ = x[SEMICOLON-10]
+	_ = x[SLASH-11]
+	_ = x[STAR-12]
+	_ = x[PERCENT-13]
+	_ = x[AMPERSAND-14]
+	_ = x[PIPE-15]
+	_ = x[CARET-16]
+	_ = x[TILDE-17]
+	_ = x[COLON-18]
+	_ = x[AT-19]
+	_ = x[BANG-20]
+	_ = x[BANG_EQUAL-21]
+	_ = x[EQUAL-22]
+	_ = x[EQUAL_EQUAL-23]
+	_ = x[GREATER-24]
+	_ = x[GREATER_EQUAL-25]
+	_ = x[GREATER_GREATER-26]
+	_ = x[LESS-27]
+	_ = x[LESS_EQUAL-28]
+	_ = x[LESS_LESS-29]
+	_ = x[STAR_STAR-30]
+	_ = x[QUESTION-31]
+	_ = x[QUESTION_DOT-32]
+	_ = x[QUESTION_QUESTION-33]
+	_ = x[PLUS_EQUAL-34]
+	_ = x[PLUS_PLUS-35]
+	_ = x[MINUS_EQUAL-36]
+	_ = x[MINUS_MINUS-37]
+	_ = x[STAR_EQUAL-38]
+	_ = x[SLASH_EQUAL-39]
+	_ = x[PERCENT_EQUAL-40]
+	_ = x[DOT_DOT-41]
+	_ = x[DOT_DOT_LESS-42]
+	_ = x[DOT_DOT_DOT-43]
+	_ = x[ARROW-44]
+	_ = x[IDENTIFIER-45]
+	_ = x[PRIVATE_NAME-46]
+	_ = x[STRING-47]
+	_ = x[NUMBER-48]
+	_ = x[DOC_COMMENT-49]
+	_ = x[AND-50]
+	_ = x[ASYNC-51]
+	_ = x[AWAIT-52]
+	_ = x[CASE-53]
+	_ = x[CLASS-54]
+	_ = x[CONST-55]
+	_ = x[ELSE-56]
+	_ = x[ENUM-57]
+	_ = x[FALSE-58]
+	_ = x[FUN-59]
+	_ = x[FOR-60]
+	_ = x[IF-61]
+	_ = x[IN-62]
+	_ = x[INTERFACE-63]
+	_ = x[LET-64]
+	_ = x[MATCH-65]
+	_ = x[NIL-66]
+	_ = x[OR-67]
+	_ = x[PRINT-68]
+	_ = x[RECORD-69]
+	_ = x[RETURN-70]
+	_ = x[SPAWN-71]
+	_ = x[SUPER-72]
+	_ = x[THIS-73]
+	_ = x[TRAIT-74]
+	_ = x[TRUE-75]
+	_ = x[VAR-76]
+	_ = x[WHILE-77]
+	_ = x[WITH-78]
+	_ = x[YIELD-79]
+	_ = x[EOF-80]
 }
 
-const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
+const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACELEFT_BRACKETRIGHT_BRACKETCOMMADOTMINUSPLUSSEMICOLONSLASHSTARPERCENTAMPERSANDPIPECARETTILDECOLONATBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALGREATER_GREATERLESSLESS_EQUALLESS_LESSSTAR_STARQUESTIONQUESTION_DOTQUESTION_QUESTIONPLUS_EQUALPLUS_PLUSMINUS_EQUALMINUS_MINUSSTAR_EQUALSLASH_EQUALPERCENT_EQUALDOT_DOTDOT_DOT_LESSDOT_DOT_DOTARROWIDENTIFIERPRIVATE_NAMESTRINGNUMBERDOC_COMMENTANDASYNCAWAITCASECLASSCONSTELSEENUMFALSEFUNFORIFININTERFACELETMATCHNILORPRINTRECORDRETURNSPAWNSUPERTHISTRAITTRUEVARWHILEWITHYIELDEOF"
 
-var _TokenType_index = [...]uint8{0, 10, 21, 31, 42, 47, 50, 55, 59, 68, 73, 77, 81, 91, 96, 107, 114, 127, 131, 141, 151, 157, 163, 166, 171, 175, 180, 183, 186, 188, 191, 193, 198, 204, 209, 213, 217, 220, 225, 228}
+var _TokenType_index = [...]uint16{0, 10, 21, 31, 42, 54, 67, 72, 75, 80, 84, 93, 98, 102, 109, 118, 122, 127, 132, 137, 139, 143, 153, 158, 169, 176, 189, 204, 208, 218, 227, 236, 244, 256, 273, 283, 292, 303, 314, 324, 335, 348, 355, 367, 378, 383, 393, 405, 411, 417, 428, 431, 436, 441, 445, 450, 455, 459, 463, 468, 471, 474, 476, 478, 487, 490, 495, 498, 500, 505, 511, 517, 522, 527, 531, 536, 540, 543, 548, 552, 557, 560}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -15,17 +15,30 @@ func main() {
 	outputDir := os.Args[1]
 	err := defineAst(outputDir, "Expr", "(interface{}, error)", []string{
 		"Assign : Token name, Expr value",
+		"Await    : Token keyword, Expr value",
 		"Binary : Expr left, Token operator, Expr right",
-		"Call     : Expr callee, Token paren, []Expr arguments",
-		"Get      : Expr object, Token name",
+		"Call     : Expr callee, Token paren, []Expr arguments, []NamedArgument named",
+		"Compound : Expr target, Token operator, Expr value",
+		"Conditional : Expr condition, Expr thenBranch, Expr elseBranch",
+		"Destructure : Pattern pattern, Token equals, Expr value",
+		"Get      : Expr object, Token name, bool optional",
 		"Grouping : Expr expression",
+		"Index    : Expr object, Token bracket, Expr index",
+		"IndexSet : Expr object, Token bracket, Expr index, Expr value",
+		"List     : Token bracket, []Expr elements",
 		"Literal : interface{} value",
 		"Logical  : Expr left, Token operator, Expr right",
+		"Map      : Token brace, []Expr keys, []Expr values",
+		"OptionalChain : Expr expression",
+		"Range    : Expr start, Token operator, Expr end",
 		"Set      : Expr object, Token name, Expr value",
+		"Spawn    : Token keyword, *Call call",
 		"Super    : Token keyword, Token method",
 		"This     : Token keyword",
 		"Unary : Token operator, Expr right",
+		"Update   : Expr target, Token operator, bool prefix",
 		"Variable : Token name",
+		"Yield    : Token keyword, Expr value",
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -33,13 +46,19 @@ func main() {
 	}
 	err = defineAst(outputDir, "Stmt", "(interface{}, error)", []string{
 		"Block : []Stmt statements",
-		"Class      : Token name, *Variable superclass, []Function methods",
+		"Class      : Token name, *Variable superclass, []*Variable traits, []*Variable interfaces, []Function methods, []Function classMethods, []Var fields, []Var classFields, bool record, []Decorator decorators, string doc",
+		"DestructureVar : Pattern pattern, Expr initializer, bool constant, string doc",
+		"Enum       : Token name, []Token members, []Function methods, string doc",
 		"Expression : Expr expression",
-		"Function   : Token name, []Token params, []Stmt body",
+		"ForIn      : Token name, Token keyword, Expr iterable, Stmt body",
+		"Function   : Token name, []Parameter params, []Stmt body, bool getter, bool generator, bool async, []Decorator decorators, string doc",
 		"If         : Expr condition, Stmt thenBranch, Stmt elseBranch",
+		"Interface  : Token name, []Function methods, string doc",
+		"Match      : Token keyword, Expr subject, []MatchCase cases",
 		"Print      : Expr expression",
 		"Return     : Token keyword, Expr value",
-		"Var        : Token name, Expr initializer",
+		"Trait      : Token name, []Function methods, string doc",
+		"Var        : Token name, Expr initializer, bool constant, string doc",
 	    "While      : Expr condition, Stmt body",
 	})
 	if err != nil {